@@ -0,0 +1,227 @@
+package fizz
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/loopfz/gadgeto/tonic"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+// CookieTag is the name of the struct tag used to
+// declare the parameters read from the request cookies.
+const CookieTag = openapi.CookieTag
+
+// FormTag is the name of the struct tag used to
+// declare the fields of a form request body.
+const FormTag = openapi.FormTag
+
+// FormBindHook returns a tonic.BindHook that binds
+// the form values and files of the multipart/form-data
+// and application/x-www-form-urlencoded request bodies
+// to the input object, and that calls the hook next for
+// the other media types.
+//
+//	tonic.SetBindHook(fizz.FormBindHook(tonic.DefaultBindingHook))
+func FormBindHook(next tonic.BindHook) tonic.BindHook {
+	return func(c *gin.Context, i interface{}) error {
+		var b binding.Binding
+
+		switch c.ContentType() {
+		case binding.MIMEMultipartPOSTForm:
+			b = binding.FormMultipart
+		case binding.MIMEPOSTForm:
+			b = binding.FormPost
+		default:
+			if next != nil {
+				return next(c, i)
+			}
+			return nil
+		}
+		if err := c.ShouldBindWith(i, b); err != nil {
+			return fmt.Errorf("error parsing request body: %s", err.Error())
+		}
+		return nil
+	}
+}
+
+// CookieBindHook returns a tonic.BindHook that binds
+// the fields of the input object that have the struct
+// tag with the given name with the value of the cookies
+// of the request, after calling the hook next, such as
+// the default binding hook of tonic. The tag must be the
+// same as the CookieLocationTag of the generator config,
+// and defaults to CookieTag if empty.
+//
+//	tonic.SetBindHook(fizz.CookieBindHook(fizz.CookieTag, tonic.DefaultBindingHook))
+func CookieBindHook(tag string, next tonic.BindHook) tonic.BindHook {
+	if tag == "" {
+		tag = CookieTag
+	}
+	return func(c *gin.Context, i interface{}) error {
+		if next != nil {
+			if err := next(c, i); err != nil {
+				return err
+			}
+		}
+		return bindCookies(c, reflect.ValueOf(i), tag)
+	}
+}
+
+// ContentTypeBindHook returns a tonic.BindHook that binds
+// the request body with the binding of its media type. The
+// JSON, XML and YAML media types are supported, including
+// the structured syntax suffixes +json and +xml, as well as
+// the form media types. A request body with a media type
+// that is not consumed by the operation is rejected, and
+// the hook next is called for the unknown media types.
+//
+//	tonic.SetBindHook(fizz.ContentTypeBindHook(tonic.DefaultBindingHook))
+func ContentTypeBindHook(next tonic.BindHook) tonic.BindHook {
+	form := FormBindHook(nil)
+
+	return func(c *gin.Context, i interface{}) error {
+		if c.Request.ContentLength == 0 || c.Request.Method == http.MethodGet {
+			return nil
+		}
+		ct := c.ContentType()
+
+		if op, err := OperationFromContext(c); err == nil && op.RequestBody != nil && ct != "" {
+			_, ok := op.RequestBody.Content[ct]
+			_, wildcard := op.RequestBody.Content["*/*"]
+			if !ok && !wildcard {
+				return fmt.Errorf("unsupported media type %s", ct)
+			}
+		}
+		var b binding.Binding
+
+		switch {
+		case ct == binding.MIMEMultipartPOSTForm, ct == binding.MIMEPOSTForm:
+			return form(c, i)
+		case ct == binding.MIMEJSON, strings.HasSuffix(ct, "+json"):
+			b = binding.JSON
+		case ct == binding.MIMEXML, ct == binding.MIMEXML2, strings.HasSuffix(ct, "+xml"):
+			b = binding.XML
+		case ct == binding.MIMEYAML:
+			b = binding.YAML
+		default:
+			if next != nil {
+				return next(c, i)
+			}
+			return nil
+		}
+		if err := c.ShouldBindWith(i, b); err != nil && err != io.EOF {
+			return fmt.Errorf("error parsing request body: %s", err.Error())
+		}
+		return nil
+	}
+}
+
+// bindCookies binds the cookies of the request to
+// the fields of the struct v that have the tag key.
+func bindCookies(c *gin.Context, v reflect.Value, key string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		field := v.Field(i)
+
+		// Handle embedded fields with a recursive call.
+		if ft.Anonymous {
+			if field.Kind() == reflect.Ptr && field.IsNil() && field.CanSet() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			if field.Kind() != reflect.Ptr && field.CanAddr() {
+				field = field.Addr()
+			}
+			if err := bindCookies(c, field, key); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := ft.Tag.Get(key)
+		if tag == "" || !field.CanSet() {
+			continue
+		}
+		name, err := tonic.ParseTagKey(tag)
+		if err != nil {
+			return err
+		}
+		value, err := c.Cookie(name)
+		if err == http.ErrNoCookie {
+			def, ok := ft.Tag.Lookup(tonic.DefaultTag)
+			if !ok {
+				continue
+			}
+			value = def
+		}
+		if err := setStringValue(field, value); err != nil {
+			return fmt.Errorf("binding error on field '%s' of type '%s': %s", ft.Name, t.Name(), err)
+		}
+	}
+	return nil
+}
+
+// setStringValue converts the string s to the type
+// of the value v, and sets it.
+func setStringValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() {
+		if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return tu.UnmarshalText([]byte(s))
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
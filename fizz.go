@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"path"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strings"
 	"time"
@@ -37,6 +38,7 @@ var (
 type Fizz struct {
 	gen    *openapi.Generator
 	engine *gin.Engine
+	strict bool
 	*RouterGroup
 }
 
@@ -44,10 +46,47 @@ type Fizz struct {
 type RouterGroup struct {
 	group       *gin.RouterGroup
 	gen         *openapi.Generator
+	options     []OperationOption
+	tags        []string
+	tagMode     TagMode
+	root        string
+	tagGroups   *tagGroups
 	Name        string
 	Description string
 }
 
+// TagMode controls the tags applied to the
+// operations of the routes of nested groups.
+type TagMode int
+
+// Tag modes.
+const (
+	// TagFlat tags the operations with the
+	// name of their group only. This is the
+	// default mode.
+	TagFlat TagMode = iota
+	// TagInherit tags the operations of a group
+	// that has no name with the tags of its parent.
+	TagInherit
+	// TagStack tags the operations with the names
+	// of their group and of all its parents.
+	TagStack
+)
+
+// TagGroups can be combined with a tag mode, such as
+// TagStack|TagGroups, to generate the x-tagGroups
+// extension of the specification from the hierarchy
+// of the named groups.
+const TagGroups TagMode = 1 << 4
+
+// tagGroups tracks the names of the top-level groups
+// used to build the x-tagGroups extension of the
+// specification once groups are nested.
+type tagGroups struct {
+	roots  []string
+	nested bool
+}
+
 // New creates a new Fizz wrapper for
 // a default Gin engine.
 func New() *Fizz {
@@ -65,6 +104,8 @@ func NewFromEngine(e *gin.Engine) *Fizz {
 			PathLocationTag:   tonic.PathTag,
 			QueryLocationTag:  tonic.QueryTag,
 			HeaderLocationTag: tonic.HeaderTag,
+			CookieLocationTag: CookieTag,
+			FormTag:           FormTag,
 			EnumTag:           tonic.EnumTag,
 			DefaultTag:        tonic.DefaultTag,
 		},
@@ -73,8 +114,9 @@ func NewFromEngine(e *gin.Engine) *Fizz {
 		engine: e,
 		gen:    gen,
 		RouterGroup: &RouterGroup{
-			group: &e.RouterGroup,
-			gen:   gen,
+			group:     &e.RouterGroup,
+			gen:       gen,
+			tagGroups: &tagGroups{},
 		},
 	}
 }
@@ -99,10 +141,28 @@ func (f *Fizz) Generator() *openapi.Generator {
 	return f.gen
 }
 
+// SetStrict controls whether the generated specification
+// is validated against the rules of the OpenAPI specification.
+// In strict mode, the validation errors are returned by Errors,
+// and the specification is not served by the handler returned
+// by OpenAPI if it is invalid.
+func (f *Fizz) SetStrict(b bool) {
+	f.strict = b
+}
+
 // Errors returns the errors that may have occurred
-// during the spec generation.
+// during the spec generation. In strict mode, it also
+// returns the validation errors of the specification.
 func (f *Fizz) Errors() []error {
-	return f.gen.Errors()
+	errs := f.gen.Errors()
+	if !f.strict {
+		return errs
+	}
+	errs = append([]error{}, errs...)
+	for _, ve := range openapi.Validate(f.gen.API()) {
+		errs = append(errs, ve)
+	}
+	return errs
 }
 
 // Group creates a new group of routes.
@@ -111,12 +171,73 @@ func (g *RouterGroup) Group(path, name, description string, handlers ...gin.Hand
 	// for this groups.
 	g.gen.AddTag(name, description)
 
-	return &RouterGroup{
+	// The options of the parent group are
+	// inherited by the new group.
+	options := make([]OperationOption, len(g.options))
+	copy(options, g.options)
+
+	grp := &RouterGroup{
 		gen:         g.gen,
 		group:       g.group.Group(path, handlers...),
+		options:     options,
+		tagMode:     g.tagMode,
+		root:        g.root,
+		tagGroups:   g.tagGroups,
 		Name:        name,
 		Description: description,
 	}
+	// The duplicate tags are removed
+	// by the generator.
+	switch mode := g.tagMode &^ TagGroups; {
+	case mode == TagStack:
+		grp.tags = append(grp.tags, g.tags...)
+		if name != "" {
+			grp.tags = append(grp.tags, name)
+		}
+	case mode == TagInherit && name == "":
+		grp.tags = append(grp.tags, g.tags...)
+	case name != "":
+		grp.tags = []string{name}
+	}
+	if name != "" && g.tagMode&TagGroups != 0 {
+		grp.addTagGroup()
+	}
+	return grp
+}
+
+// addTagGroup registers the tag of the group in the
+// x-tagGroups extension of the specification, with its
+// top-level group as the name of the tag group. The tag
+// groups are only generated once a named group is nested
+// in another, and then include all the top-level groups.
+func (g *RouterGroup) addTagGroup() {
+	tg := g.tagGroups
+	if tg == nil {
+		return
+	}
+	if g.root == "" {
+		g.root = g.Name
+		tg.roots = append(tg.roots, g.Name)
+		if tg.nested {
+			g.gen.AddTagGroup(g.Name, g.Name)
+		}
+		return
+	}
+	if !tg.nested {
+		tg.nested = true
+		for _, root := range tg.roots {
+			g.gen.AddTagGroup(root, root)
+		}
+	}
+	g.gen.AddTagGroup(g.root, g.Name)
+}
+
+// SetTagMode sets the mode used to tag the operations
+// of the groups created afterwards from this group.
+// The mode is inherited by the subgroups. The tag groups
+// are generated only if the mode includes TagGroups.
+func (g *RouterGroup) SetTagMode(mode TagMode) {
+	g.tagMode = mode
 }
 
 // Use adds middleware to the group.
@@ -124,6 +245,18 @@ func (g *RouterGroup) Use(handlers ...gin.HandlerFunc) {
 	g.group.Use(handlers...)
 }
 
+// UseOptions adds default operation options to the group,
+// applied to every route registered afterwards beneath it,
+// including the routes of its subgroups created afterwards.
+// The options of a route are applied after those of its
+// group and override them. A response or a header of a
+// route replaces the one of the group with the same code
+// or name, and the security requirements of the group can
+// be replaced using WithoutSecurity followed by Security.
+func (g *RouterGroup) UseOptions(options ...OperationOption) {
+	g.options = append(g.options, options...)
+}
+
 // GinRouterGroup returns the underlying Gin router group.
 func (g *RouterGroup) GinRouterGroup() *gin.RouterGroup {
 	return g.group
@@ -171,11 +304,23 @@ func (g *RouterGroup) TRACE(path string, infos []OperationOption, handlers ...gi
 
 // Handle registers a new request handler that is wrapped
 // with Tonic and documented in the OpenAPI specification.
+// The plain Gin handlers are documented only when one of
+// the options Input, Output or Document is used.
 func (g *RouterGroup) Handle(path, method string, infos []OperationOption, handlers ...gin.HandlerFunc) *RouterGroup {
 	oi := &openapi.OperationInfo{}
+	for _, info := range g.options {
+		info(oi)
+	}
+	nr, nh := len(oi.Responses), len(oi.Headers)
+
 	for _, info := range infos {
 		info(oi)
 	}
+	// The responses and headers of the route
+	// override those of the group.
+	oi.Responses = overrideResponses(oi.Responses, nr)
+	oi.Headers = overrideHeaders(oi.Headers, nh)
+
 	type wrap struct {
 		h gin.HandlerFunc
 		r *tonic.Route
@@ -194,28 +339,55 @@ func (g *RouterGroup) Handle(path, method string, infos []OperationOption, handl
 	if len(wrapped) > 1 {
 		panic(fmt.Sprintf("multiple tonic-wrapped handler used for operation %s %s", method, path))
 	}
+	var (
+		target gin.HandlerFunc
+		it, ot reflect.Type
+	)
 	// If we have a tonic-wrapped handler, generate the
 	// specification of this operation.
 	if len(wrapped) == 1 {
 		hfunc := wrapped[0].r
 
-		// Set an operation ID if none is provided.
+		// Set an operation ID and a status
+		// code if none are provided.
 		if oi.ID == "" {
 			oi.ID = hfunc.HandlerName()
 		}
-		oi.StatusCode = hfunc.GetDefaultStatusCode()
+		if oi.StatusCode == 0 {
+			oi.StatusCode = hfunc.GetDefaultStatusCode()
+		}
+		it, ot = hfunc.InputType(), hfunc.OutputType()
+		target = wrapped[0].h
+	} else if oi.Document && len(handlers) != 0 {
+		// A plain Gin handler is documented with the
+		// models of the operation options, and the last
+		// handler is considered as the main handler.
+		target = handlers[len(handlers)-1]
 
-		// Set an input type if provided.
-		it := hfunc.InputType()
+		if oi.ID == "" {
+			oi.ID = g.operationID(handlerName(target), method, path)
+		}
+		if oi.StatusCode == 0 {
+			oi.StatusCode = http.StatusOK
+		}
+	}
+	if target != nil {
+		// Set the input and output types if provided.
 		if oi.InputModel != nil {
 			it = reflect.TypeOf(oi.InputModel)
 		}
-
+		if oi.OutputModel != nil {
+			ot = reflect.TypeOf(oi.OutputModel)
+		}
 		// Consolidate path for OpenAPI spec.
 		operationPath := joinPaths(g.group.BasePath(), path)
 
 		// Add operation to the OpenAPI spec.
-		operation, err := g.gen.AddOperation(operationPath, method, g.Name, it, hfunc.OutputType(), oi)
+		// The tags of the group are applied
+		// before those of the operation.
+		oi.Tags = append(append([]string{}, g.tags...), oi.Tags...)
+
+		operation, err := g.gen.AddOperation(operationPath, method, "", it, ot, oi)
 		if err != nil {
 			panic(fmt.Sprintf(
 				"error while generating OpenAPI spec on operation %s %s: %s",
@@ -223,11 +395,11 @@ func (g *RouterGroup) Handle(path, method string, infos []OperationOption, handl
 			))
 		}
 		// If an operation was generated for the handler,
-		// wrap the Tonic-wrapped handled with a closure
-		// to inject it into the Gin context.
+		// wrap the main handler with a closure to inject
+		// it into the Gin context.
 		if operation != nil {
 			for i, h := range handlers {
-				if funcEqual(h, wrapped[0].h) {
+				if funcEqual(h, target) {
 					orig := h // copy the original func
 					handlers[i] = func(c *gin.Context) {
 						c.Set(ctxOpenAPIOperation, operation)
@@ -243,6 +415,48 @@ func (g *RouterGroup) Handle(path, method string, infos []OperationOption, handl
 	return g
 }
 
+// overrideResponses removes the first n responses that
+// have the same code as one of the following responses.
+func overrideResponses(responses []*openapi.OperationResponse, n int) []*openapi.OperationResponse {
+	codes := make(map[string]struct{})
+	for _, r := range responses[n:] {
+		if r != nil {
+			codes[r.Code] = struct{}{}
+		}
+	}
+	var ret []*openapi.OperationResponse
+	for i, r := range responses {
+		if r != nil && i < n {
+			if _, ok := codes[r.Code]; ok {
+				continue
+			}
+		}
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// overrideHeaders removes the first n headers that have
+// the same name as one of the following headers.
+func overrideHeaders(headers []*openapi.ResponseHeader, n int) []*openapi.ResponseHeader {
+	names := make(map[string]struct{})
+	for _, h := range headers[n:] {
+		if h != nil {
+			names[h.Name] = struct{}{}
+		}
+	}
+	var ret []*openapi.ResponseHeader
+	for i, h := range headers {
+		if h != nil && i < n {
+			if _, ok := names[h.Name]; ok {
+				continue
+			}
+		}
+		ret = append(ret, h)
+	}
+	return ret
+}
+
 // OpenAPI returns a Gin HandlerFunc that serves
 // the marshalled OpenAPI specification of the API.
 func (f *Fizz) OpenAPI(info *openapi.Info, ct string) gin.HandlerFunc {
@@ -252,17 +466,29 @@ func (f *Fizz) OpenAPI(info *openapi.Info, ct string) gin.HandlerFunc {
 	if ct == "" {
 		ct = "json"
 	}
+	var render func(*gin.Context, int, interface{})
+
 	switch ct {
 	case "json":
-		return func(c *gin.Context) {
-			c.JSON(200, f.gen.API())
-		}
+		render = (*gin.Context).JSON
 	case "yaml":
-		return func(c *gin.Context) {
-			c.YAML(200, f.gen.API())
+		render = (*gin.Context).YAML
+	default:
+		panic("invalid content type, use JSON or YAML")
+	}
+	return func(c *gin.Context) {
+		api := f.gen.API()
+
+		// In strict mode, an invalid specification
+		// is replaced by its validation errors.
+		if f.strict {
+			if errs := openapi.Validate(api); len(errs) != 0 {
+				render(c, http.StatusInternalServerError, gin.H{"errors": errs})
+				return
+			}
 		}
+		render(c, http.StatusOK, api)
 	}
-	panic("invalid content type, use JSON or YAML")
 }
 
 // OperationOption represents an option-pattern function
@@ -357,13 +583,119 @@ func Header(name, desc string, model interface{}) func(*openapi.OperationInfo) {
 	}
 }
 
+// Tags adds tags to the operation, in addition
+// to the tags of its group.
+func Tags(tags ...string) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// ParameterRef adds a reference to a parameter registered
+// in the components of the specification to the operation.
+func ParameterRef(name string) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.ParameterRefs = append(o.ParameterRefs, name)
+	}
+}
+
+// ResponseRef adds an additional response to the operation
+// that references a response registered in the components
+// of the specification.
+func ResponseRef(statusCode, name string) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.Responses = append(o.Responses, &openapi.OperationResponse{
+			Code: statusCode,
+			Ref:  name,
+		})
+	}
+}
+
+// HeaderRef adds a header to the operation that references
+// a header registered in the components of the specification.
+func HeaderRef(name, ref string) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.Headers = append(o.Headers, &openapi.ResponseHeader{
+			Name: name,
+			Ref:  ref,
+		})
+	}
+}
+
 // InputModel overrides the binding model of the operation.
+// Unlike Input, it doesn't mark the routes registered with
+// plain Gin handlers to be documented.
 func InputModel(model interface{}) func(*openapi.OperationInfo) {
 	return func(o *openapi.OperationInfo) {
 		o.InputModel = model
 	}
 }
 
+// Input sets the input model of the operation, which
+// overrides the binding model of a tonic handler. It
+// also marks a route registered with plain Gin handlers
+// to be documented, see Document, with the parameters
+// and request body of the model.
+func Input(model interface{}) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.InputModel = model
+		o.Document = true
+	}
+}
+
+// Output sets the output model and the status code
+// of the default response of the operation, which
+// override those of a tonic handler. For a plain Gin
+// handler, the status code default to 200, and the
+// model may be nil if the response has no body.
+func Output(model interface{}, status int) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.OutputModel = model
+		o.StatusCode = status
+		o.Document = true
+	}
+}
+
+// Document marks a route registered with plain Gin
+// handlers to be documented in the specification. The
+// operation ID default to the name of the last handler,
+// or to an ID made of the method and the path if it is
+// an anonymous function.
+func Document() func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.Document = true
+	}
+}
+
+// Consumes sets the media types of the request body
+// of the operation, which default to the media type
+// of tonic.
+func Consumes(mediaTypes ...string) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.Consumes = append(o.Consumes, mediaTypes...)
+	}
+}
+
+// Produces sets the media types of the responses
+// of the operation, which default to the media type
+// of tonic.
+func Produces(mediaTypes ...string) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		o.Produces = append(o.Produces, mediaTypes...)
+	}
+}
+
+// ResponseProduces overrides the media types of the
+// response of the operation with the given status code.
+func ResponseProduces(statusCode string, mediaTypes ...string) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		if o.ResponseMediaTypes == nil {
+			o.ResponseMediaTypes = make(map[string][]string)
+		}
+		o.ResponseMediaTypes[statusCode] = append(o.ResponseMediaTypes[statusCode], mediaTypes...)
+	}
+}
+
 // XCodeSample adds a code sample to the operation.
 func XCodeSample(cs *openapi.XCodeSample) func(*openapi.OperationInfo) {
 	return func(o *openapi.OperationInfo) {
@@ -401,6 +733,20 @@ func XInternal() func(*openapi.OperationInfo) {
 	}
 }
 
+// Extension adds a vendor extension to the operation.
+// The key must start with x-, such as x-rate-limit, and
+// must not be one of the extensions modeled by the
+// operation, such as x-codeSamples, otherwise the
+// registration of the route panics.
+func Extension(key string, value interface{}) func(*openapi.OperationInfo) {
+	return func(o *openapi.OperationInfo) {
+		if o.Extensions == nil {
+			o.Extensions = make(map[string]interface{})
+		}
+		o.Extensions[key] = value
+	}
+}
+
 // OperationFromContext returns the OpenAPI operation from
 // the given Gin context or an error if none is found.
 func OperationFromContext(ctx context.Context) (*openapi.Operation, error) {
@@ -432,6 +778,38 @@ func lastChar(str string) uint8 {
 	return str[len(str)-1]
 }
 
+// operationID returns the default ID of an operation
+// documented with plain Gin handlers, which is the name
+// of its last handler, unless the handler is an anonymous
+// function, such as func1, or is shared with another
+// operation. In that case, the ID is made of the method
+// and the path of the operation.
+func (g *RouterGroup) operationID(handler, method, path string) string {
+	handler = strings.TrimSuffix(handler, "-fm")
+
+	if anonymousFuncRe.MatchString(handler) || g.gen.HasOperationID(handler) {
+		return openapi.OperationID(method, joinPaths(g.group.BasePath(), path))
+	}
+	return handler
+}
+
+// anonymousFuncRe matches the names of the
+// anonymous functions, such as func1 or 2 for
+// a function nested in another one.
+var anonymousFuncRe = regexp.MustCompile(`^(func)?[0-9]+$`)
+
+// handlerName returns the name of the function of
+// the handler h, without its package path.
+func handlerName(h gin.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+
+	if i := strings.LastIndexByte(name, '.'); i != -1 {
+		name = name[i+1:]
+	}
+	return name
+}
+
 func funcEqual(f1, f2 interface{}) bool {
 	v1 := reflect.ValueOf(f1)
 	v2 := reflect.ValueOf(f2)
@@ -1,14 +1,19 @@
 package fizz
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -60,6 +65,429 @@ func TestGroup(t *testing.T) {
 	assert.NotNil(t, grp.group)
 }
 
+// TestGroupOptions tests that the default options of
+// a group are applied to the routes registered beneath
+// it, and can be overridden by the options of a route.
+func TestGroupOptions(t *testing.T) {
+	fizz := New()
+
+	admin := fizz.Group("/admin", "Admin", "Admin routes")
+	admin.UseOptions(
+		Security(&openapi.SecurityRequirement{"apiKey": []string{}}),
+		Response("401", "Unauthorized", nil, nil, nil),
+		Response("500", "Internal error", nil, nil, nil),
+		Header("X-Request-ID", "Request ID", String),
+		XInternal(),
+	)
+	users := admin.Group("/users", "Users", "Users routes")
+
+	handler := tonic.Handler(func(c *gin.Context) error { return nil }, http.StatusNoContent)
+
+	admin.GET("/stats", []OperationOption{ID("Stats")}, handler)
+	users.GET("/", []OperationOption{
+		ID("ListUsers"),
+		Response("500", "Database error", nil, nil, nil),
+		Deprecated(true),
+	}, handler)
+	users.GET("/public", []OperationOption{
+		ID("ListPublicUsers"),
+		WithoutSecurity(),
+		Header("X-Request-ID", "Correlation ID", String),
+	}, handler)
+
+	// The options of a group are not
+	// applied to its parent.
+	fizz.GET("/health", []OperationOption{ID("Health")}, handler)
+
+	gen := fizz.Generator()
+
+	op := gen.Operation("/admin/stats", "GET")
+	if assert.NotNil(t, op) {
+		assert.True(t, op.XInternal)
+		assert.Len(t, op.Security, 1)
+		assert.Contains(t, op.Responses, "401")
+		assert.Equal(t, "Internal error", op.Responses["500"].Description)
+		assert.Contains(t, op.Responses["204"].Headers, "X-Request-ID")
+	}
+	op = gen.Operation("/admin/users/", "GET")
+	if assert.NotNil(t, op) {
+		assert.True(t, op.XInternal)
+		assert.True(t, op.Deprecated)
+		assert.Len(t, op.Security, 1)
+		assert.Contains(t, op.Responses, "401")
+		assert.Equal(t, "Database error", op.Responses["500"].Description)
+	}
+	op = gen.Operation("/admin/users/public", "GET")
+	if assert.NotNil(t, op) {
+		assert.NotNil(t, op.Security)
+		assert.Empty(t, op.Security)
+		assert.Equal(t, "Correlation ID", op.Responses["204"].Headers["X-Request-ID"].Description)
+	}
+	op = gen.Operation("/health", "GET")
+	if assert.NotNil(t, op) {
+		assert.False(t, op.XInternal)
+		assert.Nil(t, op.Security)
+		assert.NotContains(t, op.Responses, "401")
+	}
+	assert.Empty(t, fizz.Errors())
+}
+
+type testEvent struct {
+	ID   string `json:"id"`
+	Data string `json:"data"`
+}
+
+type testEventsInput struct {
+	Since string `query:"since"`
+}
+
+func streamEvents(c *gin.Context) {
+	op, err := OperationFromContext(c)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.String(http.StatusOK, op.ID)
+}
+
+// TestPlainHandlers tests that the routes registered
+// with plain Gin handlers are documented with the models
+// of the operation options.
+func TestPlainHandlers(t *testing.T) {
+	fizz := New()
+
+	noop := func(c *gin.Context) {}
+
+	fizz.GET("/events", []OperationOption{
+		Input(&testEventsInput{}),
+		Output([]*testEvent{}, 0),
+	}, noop, streamEvents)
+	fizz.DELETE("/events", []OperationOption{
+		ID("PurgeEvents"),
+		Output(nil, http.StatusNoContent),
+	}, noop)
+	fizz.GET("/proxy", []OperationOption{
+		ID("Proxy"),
+		Document(),
+	}, noop)
+	fizz.GET("/legacy", nil, noop)
+
+	// The output of a tonic handler can be overridden.
+	fizz.GET("/status", []OperationOption{
+		ID("Status"),
+		Output(&testEvent{}, http.StatusAccepted),
+	}, tonic.Handler(func(c *gin.Context) error { return nil }, http.StatusNoContent))
+
+	gen := fizz.Generator()
+
+	op := gen.Operation("/events", "GET")
+	if assert.NotNil(t, op) {
+		assert.Equal(t, "streamEvents", op.ID)
+		assert.Len(t, op.Parameters, 1)
+		if assert.Contains(t, op.Responses, "200") {
+			schema := op.Responses["200"].Content["application/json"].Schema
+			assert.Equal(t, "array", schema.Type)
+		}
+	}
+	op = gen.Operation("/events", "DELETE")
+	if assert.NotNil(t, op) {
+		assert.Contains(t, op.Responses, "204")
+		assert.Empty(t, op.Responses["204"].Content)
+	}
+	assert.NotNil(t, gen.Operation("/proxy", "GET"))
+	assert.Nil(t, gen.Operation("/legacy", "GET"))
+
+	op = gen.Operation("/status", "GET")
+	if assert.NotNil(t, op) {
+		assert.NotContains(t, op.Responses, "204")
+		assert.Contains(t, op.Responses["202"].Content, "application/json")
+	}
+	assert.Empty(t, fizz.Errors())
+
+	// The operation is injected into the
+	// context of the main handler.
+	srv := httptest.NewServer(fizz)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "streamEvents", string(body))
+}
+
+// TestDefaultOperationID tests that the default ID of
+// the operations of anonymous or shared plain handlers is
+// made of the method and the path of the operation, and
+// that the ID of a tonic handler is its name.
+func TestDefaultOperationID(t *testing.T) {
+	fizz := New()
+
+	for _, path := range []string{"/a", "/b"} {
+		fizz.GET(path, []OperationOption{Document()}, func(c *gin.Context) {})
+	}
+	handler := func(c *gin.Context) error { return nil }
+	fizz.GET("/tonic", nil, tonic.Handler(handler, http.StatusNoContent))
+
+	// A tonic handler shared by several
+	// routes has a duplicate ID.
+	assert.Panics(t, func() {
+		fizz.GET("/tonic/shared", nil, tonic.Handler(handler, http.StatusNoContent))
+	})
+	fizz.GET("/events", []OperationOption{Document()}, streamEvents)
+	fizz.GET("/events/:id", []OperationOption{Document()}, streamEvents)
+
+	// InputModel doesn't document a plain handler.
+	fizz.GET("/legacy", []OperationOption{InputModel(&testEventsInput{})}, streamEvents)
+
+	gen := fizz.Generator()
+	for path, id := range map[string]string{
+		"/a":           "getA",
+		"/b":           "getB",
+		"/events":      "streamEvents",
+		"/events/{id}": "getEventsId",
+	} {
+		if op := gen.Operation(path, "GET"); assert.NotNil(t, op, path) {
+			assert.Equal(t, id, op.ID, path)
+		}
+	}
+	if op := gen.Operation("/tonic", "GET"); assert.NotNil(t, op) {
+		assert.Regexp(t, `^func[0-9]+$`, op.ID)
+	}
+	assert.Nil(t, gen.Operation("/legacy", "GET"))
+	assert.Empty(t, fizz.Errors())
+}
+
+// TestStrictMode tests that the validation errors of the
+// specification are reported and served in strict mode.
+func TestStrictMode(t *testing.T) {
+	fizz := New()
+
+	fizz.GET("/test", []OperationOption{
+		ID("Test"),
+		Security(&openapi.SecurityRequirement{"apiKey": []string{}}),
+	}, tonic.Handler(func(c *gin.Context) error { return nil }, http.StatusNoContent))
+
+	fizz.GET("/openapi.json", nil, fizz.OpenAPI(&openapi.Info{Title: "Test", Version: "1.0"}, "json"))
+
+	assert.Empty(t, fizz.Errors())
+
+	fizz.SetStrict(true)
+
+	errs := fizz.Errors()
+	if assert.Len(t, errs, 1) {
+		ve, ok := errs[0].(*openapi.ValidationError)
+		if assert.True(t, ok) {
+			assert.Equal(t, "/paths/~1test/get/security/0/apiKey", ve.Pointer)
+		}
+	}
+	srv := httptest.NewServer(fizz)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Errors []*openapi.ValidationError `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Len(t, body.Errors, 1)
+}
+
+type (
+	testPet interface{ isPet() }
+	testCat struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	}
+	testDog struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	}
+)
+
+func (testCat) isPet() {}
+func (testDog) isPet() {}
+
+// TestStrictModePolymorphicExample tests that the example
+// of a polymorphic type, whose implementations have the
+// same properties, is validated against the implementation
+// selected by the discriminator in strict mode.
+func TestStrictModePolymorphicExample(t *testing.T) {
+	fizz := New()
+	fizz.SetStrict(true)
+
+	err := fizz.Generator().RegisterOneOf(reflect.TypeOf((*testPet)(nil)).Elem(), "kind", map[string]interface{}{
+		"cat": testCat{},
+		"dog": testDog{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type out struct {
+		Pet testPet `json:"pet"`
+	}
+	fizz.GET("/pet", []OperationOption{
+		ID("GetPet"),
+		Response("400", "Bad request", out{}, nil, out{Pet: testCat{Kind: "cat", Name: "Tom"}}),
+	}, tonic.Handler(func(c *gin.Context) (*out, error) { return &out{}, nil }, http.StatusOK))
+
+	fizz.GET("/openapi.json", nil, fizz.OpenAPI(&openapi.Info{Title: "Test", Version: "1.0"}, "json"))
+
+	assert.Empty(t, fizz.Errors())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	fizz.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestExtensions tests that the vendor extensions of the
+// operations and of the schemas of the fields are added
+// to the specification.
+func TestExtensions(t *testing.T) {
+	type item struct {
+		ID    string   `json:"id" openapi:"x-go-type=uuid.UUID,x-order=1"`
+		Flags []string `json:"flags" openapi:"x-nullable, x-enum-varnames=[\"A\",\"B\"]"`
+		Name  string   `json:"name"`
+		Label string   `json:"label" openapi:"x-label=\"a, {b}\",x-order=2"`
+	}
+	fizz := New()
+
+	fizz.GET("/items", []OperationOption{
+		ID("ListItems"),
+		Extension("x-rate-limit", 100),
+		Extension("x-internal-name", "items"),
+	}, tonic.Handler(func(c *gin.Context) ([]*item, error) {
+		return nil, nil
+	}, 200))
+
+	assert.Empty(t, fizz.Errors())
+
+	api := fizz.Generator().API()
+	op := api.Paths["/items"].GET
+	assert.Equal(t, map[string]interface{}{
+		"x-rate-limit":    100,
+		"x-internal-name": "items",
+	}, op.Extensions)
+
+	schema := api.Components.Schemas["FizzItem"].Schema
+	if assert.NotNil(t, schema) {
+		assert.Equal(t, map[string]interface{}{
+			"x-go-type": "uuid.UUID",
+			"x-order":   float64(1),
+		}, schema.Properties["id"].Extensions)
+		assert.Equal(t, map[string]interface{}{
+			"x-nullable":      true,
+			"x-enum-varnames": []interface{}{"A", "B"},
+		}, schema.Properties["flags"].Extensions)
+		assert.Equal(t, map[string]interface{}{
+			"x-label": "a, {b}",
+			"x-order": float64(2),
+		}, schema.Properties["label"].Extensions)
+		assert.Nil(t, schema.Properties["name"].Extensions)
+	}
+	b, err := json.Marshal(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(b), `"x-internal-name":"items","x-rate-limit":100`)
+
+	// The keys of the extensions must start with x-.
+	fizz.GET("/invalid", []OperationOption{ID("Invalid")}, tonic.Handler(func(c *gin.Context) (*struct {
+		A string `json:"a" openapi:"order=1"`
+	}, error) {
+		return nil, nil
+	}, 200))
+
+	if assert.Len(t, fizz.Errors(), 1) {
+		assert.Contains(t, fizz.Errors()[0].Error(), "extension order must start with x-")
+	}
+	// The registration of an operation with an invalid
+	// extension, or one that is modeled by the operation,
+	// panics.
+	for _, key := range []string{"rate-limit", "x-internal"} {
+		assert.Panics(t, func() {
+			fizz.GET("/"+key, []OperationOption{Extension(key, true)}, tonic.Handler(func(c *gin.Context) error {
+				return nil
+			}, 200))
+		})
+	}
+}
+
+// TestNestedGroups tests the tags of the operations of
+// nested groups, and the generation of the x-tagGroups.
+func TestNestedGroups(t *testing.T) {
+	fizz := New()
+
+	handler := tonic.Handler(func(c *gin.Context) error { return nil }, http.StatusNoContent)
+
+	// The tag groups are only generated
+	// with the TagGroups mode.
+	fizz.Group("/nogroup", "NoGroup", "").Group("/nested", "Nested", "")
+	assert.Empty(t, fizz.Generator().API().XTagGroups)
+
+	fizz.SetTagMode(TagGroups)
+
+	users := fizz.Group("/users", "Users", "Users routes")
+	users.GET("/", []OperationOption{ID("ListUsers")}, handler)
+
+	// Without nested groups, no
+	// tag groups are generated.
+	assert.Empty(t, fizz.Generator().API().XTagGroups)
+
+	billing := fizz.Group("/billing", "Billing", "Billing routes")
+	billing.SetTagMode(TagStack | TagGroups)
+
+	invoices := billing.Group("/invoices", "Invoices", "Invoices routes")
+	invoices.GET("/", []OperationOption{ID("ListInvoices"), Tags("Accounting")}, handler)
+
+	payments := billing.Group("/payments", "Payments", "Payments routes")
+	payments.SetTagMode(TagInherit | TagGroups)
+	payments.GET("/", []OperationOption{ID("ListPayments")}, handler)
+	payments.Group("/refunds", "", "").GET("/", []OperationOption{ID("ListRefunds")}, handler)
+
+	// Flat mode.
+	admin := fizz.Group("/admin", "Admin", "Admin routes")
+	admin.Group("/logs", "Logs", "Logs routes").GET("/", []OperationOption{ID("ListLogs")}, handler)
+	admin.Group("/none", "", "").GET("/", []OperationOption{ID("None")}, handler)
+
+	gen := fizz.Generator()
+
+	for path, tags := range map[string][]string{
+		"/users/":                    {"Users"},
+		"/billing/invoices/":         {"Billing", "Invoices", "Accounting"},
+		"/billing/payments/":         {"Billing", "Payments"},
+		"/billing/payments/refunds/": {"Billing", "Payments"},
+		"/admin/logs/":               {"Logs"},
+		"/admin/none/":               nil,
+	} {
+		op := gen.Operation(path, "GET")
+		if assert.NotNil(t, op, path) {
+			assert.Equal(t, tags, op.Tags, path)
+		}
+	}
+	assert.Equal(t, []*openapi.XTagGroup{
+		{Name: "Users", Tags: []string{"Users"}},
+		{Name: "Billing", Tags: []string{"Billing", "Invoices", "Payments"}},
+		{Name: "Admin", Tags: []string{"Admin", "Logs"}},
+	}, gen.API().XTagGroups)
+}
+
 // TestHandler tests that handlers can be
 // registered on the Fizz instance.
 func TestHandler(t *testing.T) {
@@ -444,6 +872,74 @@ func TestSpecHandler(t *testing.T) {
 	}
 }
 
+// TestUIHandlers tests that the documentation
+// pages are rendered for the spec route.
+func TestUIHandlers(t *testing.T) {
+	fizz := New()
+
+	infos := &openapi.Info{
+		Title:   "Test Server",
+		Version: "1.0.0",
+	}
+	fizz.GET("/openapi.json", nil, fizz.OpenAPI(infos, "json"))
+	fizz.GET("/swagger", nil, fizz.SwaggerUI("/openapi.json", &UIOptions{CDN: true}))
+	fizz.GET("/redoc", nil, fizz.Redoc("/openapi.json", &UIOptions{
+		Title:  "Redoc",
+		Config: map[string]interface{}{"hideDownloadButton": true},
+		CDN:    true,
+	}))
+	fizz.GET("/rapidoc", nil, fizz.RapiDoc("/openapi.json", nil))
+
+	tests := []struct {
+		path     string
+		title    string
+		contains string
+	}{
+		{"/swagger", "Test Server", "SwaggerUIBundle"},
+		{"/redoc", "Redoc", `{"hideDownloadButton":true}`},
+		{"/rapidoc", "Test Server", "rapi-doc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fizz.ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+			body := recorder.Body.String()
+			assert.Contains(t, body, "<title>"+tt.title+"</title>")
+			assert.Contains(t, body, `"/openapi.json"`)
+			assert.Contains(t, body, tt.contains)
+		})
+	}
+}
+
+// TestUIHandlersMissingAssets tests that the assets
+// that are not embedded are loaded from the CDN.
+func TestUIHandlersMissingAssets(t *testing.T) {
+	if _, err := fs.Stat(uiFS, "ui/dist/swagger-ui-bundle.js"); err == nil {
+		t.Skip("assets are embedded")
+	}
+	fizz := New()
+	fizz.GET("/swagger", nil, fizz.SwaggerUI("/openapi.json", nil))
+
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/swagger", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fizz.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), swaggerUICDN+"swagger-ui-bundle.js")
+	assert.Contains(t, recorder.Body.String(), swaggerUICDN+"swagger-ui.css")
+}
+
 // TestInvalidContentTypeOpenAPIHandler tests that the
 // OpenAPI handler will panic if the given content type
 // is invalid.
@@ -560,6 +1056,477 @@ func TestOperationContext(t *testing.T) {
 	}
 }
 
+// TestRequestValidator tests that the requests are
+// validated against the specification of the operation
+// by the request validation middleware.
+func TestRequestValidator(t *testing.T) {
+	type In struct {
+		ID     int      `path:"id" validate:"max=100"`
+		Fields []string `query:"fields" enum:"a,b,c"`
+		Trace  string   `header:"X-Trace" validate:"required"`
+		Name   string   `json:"name" validate:"required,min=3"`
+		Color  *string  `json:"color" enum:"red,blue"`
+	}
+	fizz := New()
+	fizz.Use(fizz.RequestValidator())
+
+	fizz.POST("/items/:id", nil, tonic.Handler(func(c *gin.Context, in *In) error {
+		return nil
+	}, http.StatusNoContent))
+
+	for _, tt := range []struct {
+		url      string
+		trace    string
+		body     string
+		status   int
+		pointers []string
+	}{
+		{"/items/10?fields=a&fields=c", "x", `{"name":"foo","color":"red"}`, http.StatusNoContent, nil},
+		{"/items/10", "x", `{"name":"foo","color":null}`, http.StatusNoContent, nil},
+		{
+			"/items/200?fields=a&fields=d", "", `{"name":"fo","color":"green"}`, http.StatusBadRequest,
+			[]string{"/path/id", "/query/fields/1", "/header/X-Trace", "/body/color", "/body/name"},
+		},
+		{"/items/foo", "x", `{}`, http.StatusBadRequest, []string{"/path/id", "/body/name"}},
+		{"/items/10", "x", `{`, http.StatusBadRequest, []string{"/body"}},
+		{"/items/10?fields=a,b", "x", `{"name":"foo"}`, http.StatusBadRequest, []string{"/query/fields/0"}},
+	} {
+		req, err := http.NewRequest("POST", tt.url, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if tt.trace != "" {
+			req.Header.Set("X-Trace", tt.trace)
+		}
+		recorder := httptest.NewRecorder()
+		fizz.ServeHTTP(recorder, req)
+
+		if !assert.Equal(t, tt.status, recorder.Code, tt.url) {
+			continue
+		}
+		if tt.status != http.StatusBadRequest {
+			continue
+		}
+		var resp struct {
+			Violations []*openapi.ValueError `json:"violations"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		var pointers []string
+		for _, v := range resp.Violations {
+			pointers = append(pointers, v.Pointer)
+		}
+		assert.ElementsMatch(t, tt.pointers, pointers, tt.url)
+	}
+}
+
+// TestParseParamValue tests that the array values of
+// the parameters are split according to their style.
+func TestParseParamValue(t *testing.T) {
+	api := &openapi.OpenAPI{}
+	schema := &openapi.SchemaOrRef{Schema: &openapi.Schema{
+		Type:  "array",
+		Items: &openapi.SchemaOrRef{Schema: &openapi.Schema{Type: "string"}},
+	}}
+	for _, tt := range []struct {
+		param  *openapi.Parameter
+		values []string
+		want   []interface{}
+	}{
+		{&openapi.Parameter{In: "query"}, []string{"a,b", "c"}, []interface{}{"a,b", "c"}},
+		{&openapi.Parameter{In: "query", Style: "form", Explode: true}, []string{"a,b"}, []interface{}{"a,b"}},
+		{&openapi.Parameter{In: "query", Style: "form"}, []string{"a,b", "c"}, []interface{}{"a", "b", "c"}},
+		{&openapi.Parameter{In: "query", Style: "pipeDelimited"}, []string{"a|b"}, []interface{}{"a", "b"}},
+		{&openapi.Parameter{In: "query", Style: "spaceDelimited"}, []string{"a b"}, []interface{}{"a", "b"}},
+		{&openapi.Parameter{In: "header"}, []string{"a,b"}, []interface{}{"a", "b"}},
+		{&openapi.Parameter{In: "path", Explode: true}, []string{"a,b"}, []interface{}{"a,b"}},
+	} {
+		tt.param.Schema = schema
+		assert.Equal(t, tt.want, parseParamValue(api, tt.param, tt.values), tt.param)
+	}
+}
+
+// TestResponseValidator tests that the responses are
+// validated against the specification of the operation
+// and that the violations are reported to the callback.
+func TestResponseValidator(t *testing.T) {
+	type Out struct {
+		Name  string `json:"name,omitempty" validate:"required"`
+		Count int    `json:"count" validate:"max=10"`
+	}
+	var errs []*ResponseValidationError
+
+	fizz := New()
+	fizz.Use(fizz.ResponseValidator(func(c *gin.Context, err *ResponseValidationError) {
+		errs = append(errs, err)
+	}))
+	fizz.GET("/valid", nil, tonic.Handler(func(c *gin.Context) (*Out, error) {
+		return &Out{Name: "foo", Count: 1}, nil
+	}, http.StatusOK))
+
+	fizz.GET("/invalid", nil, tonic.Handler(func(c *gin.Context) (*Out, error) {
+		return &Out{Count: 42}, nil
+	}, http.StatusOK))
+
+	fizz.GET("/undocumented", nil, tonic.Handler(func(c *gin.Context) (*Out, error) {
+		return nil, errors.New("error")
+	}, http.StatusOK))
+
+	for _, tt := range []struct {
+		url      string
+		status   int
+		pointers []string
+	}{
+		{"/valid", http.StatusOK, nil},
+		{"/invalid", http.StatusOK, []string{"/body/name", "/body/count"}},
+		{"/undocumented", http.StatusBadRequest, []string{"/status"}},
+	} {
+		errs = nil
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fizz.ServeHTTP(recorder, req)
+
+		assert.Equal(t, tt.status, recorder.Code, tt.url)
+		assert.NotEmpty(t, recorder.Body.String(), tt.url)
+
+		if tt.pointers == nil {
+			assert.Empty(t, errs, tt.url)
+			continue
+		}
+		if assert.Len(t, errs, 1, tt.url) {
+			var pointers []string
+			for _, v := range errs[0].Violations {
+				pointers = append(pointers, v.Pointer)
+			}
+			assert.ElementsMatch(t, tt.pointers, pointers, tt.url)
+			assert.Equal(t, tt.status, errs[0].StatusCode)
+		}
+	}
+}
+
+// TestResponseValidatorBypass tests that the handlers
+// cannot flush or hijack a buffered response.
+func TestResponseValidatorBypass(t *testing.T) {
+	type Out struct {
+		Name string `json:"name,omitempty" validate:"required"`
+	}
+	var errs []*ResponseValidationError
+
+	fizz := New()
+	fizz.Use(fizz.ResponseValidator(func(c *gin.Context, err *ResponseValidationError) {
+		errs = append(errs, err)
+	}))
+	fizz.GET("/flush", nil, tonic.Handler(func(c *gin.Context) (*Out, error) {
+		c.Writer.Flush()
+		return &Out{}, nil
+	}, http.StatusOK))
+
+	fizz.GET("/hijack", nil, tonic.Handler(func(c *gin.Context) (*Out, error) {
+		_, _, err := c.Writer.Hijack()
+		return nil, err
+	}, http.StatusOK))
+
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/flush", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fizz.ServeHTTP(recorder, req)
+
+	assert.False(t, recorder.Flushed)
+	assert.Equal(t, `{}`, recorder.Body.String())
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "/body/name", errs[0].Violations[0].Pointer)
+	}
+	recorder = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "/hijack", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fizz.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), errHijackBuffered.Error())
+}
+
+// TestDebugResponseValidator tests that the responses
+// are validated only when Gin runs in debug mode.
+func TestDebugResponseValidator(t *testing.T) {
+	defer gin.SetMode(gin.Mode())
+
+	type Out struct {
+		Name string `json:"name,omitempty" validate:"required"`
+	}
+	var errs []*ResponseValidationError
+
+	fizz := New()
+	fizz.Use(fizz.DebugResponseValidator(func(c *gin.Context, err *ResponseValidationError) {
+		errs = append(errs, err)
+	}))
+	fizz.GET("/invalid", nil, tonic.Handler(func(c *gin.Context) (*Out, error) {
+		return &Out{}, nil
+	}, http.StatusOK))
+
+	for _, mode := range []string{gin.ReleaseMode, gin.DebugMode} {
+		gin.SetMode(mode)
+		errs = nil
+
+		recorder := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/invalid", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fizz.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code, mode)
+		if mode == gin.DebugMode {
+			assert.Len(t, errs, 1, mode)
+		} else {
+			assert.Empty(t, errs, mode)
+		}
+	}
+}
+
+// TestCookieBindHook tests that the cookie parameters
+// are bound by the cookie binding hook.
+func TestCookieBindHook(t *testing.T) {
+	hook := tonic.GetBindHook()
+	defer tonic.SetBindHook(hook)
+
+	tonic.SetBindHook(CookieBindHook(CookieTag, hook))
+
+	type In struct {
+		Session string `cookie:"session" validate:"required"`
+		Count   *int   `cookie:"count"`
+		Theme   string `cookie:"theme" default:"dark"`
+	}
+	fizz := New()
+	fizz.GET("/cookies", nil, tonic.Handler(func(c *gin.Context, in *In) (string, error) {
+		return fmt.Sprintf("%s-%d-%s", in.Session, *in.Count, in.Theme), nil
+	}, http.StatusOK))
+
+	req, err := http.NewRequest("GET", "/cookies", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.AddCookie(&http.Cookie{Name: "count", Value: "42"})
+
+	recorder := httptest.NewRecorder()
+	fizz.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"abc-42-dark"`, recorder.Body.String())
+
+	// Missing required cookie.
+	req, err = http.NewRequest("GET", "/cookies", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder = httptest.NewRecorder()
+	fizz.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Invalid value.
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.AddCookie(&http.Cookie{Name: "count", Value: "foo"})
+
+	recorder = httptest.NewRecorder()
+	fizz.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// The parameters are documented.
+	op := fizz.Generator().Operation("/cookies", "GET")
+	if assert.NotNil(t, op) && assert.Len(t, op.Parameters, 3) {
+		for _, p := range op.Parameters {
+			assert.Equal(t, "cookie", p.In)
+		}
+	}
+}
+
+// TestCookieBindHookTag tests that the cookie binding
+// hook reads the struct tag with the given name.
+func TestCookieBindHookTag(t *testing.T) {
+	type In struct {
+		Session string `c:"session"`
+		Theme   string `cookie:"theme"`
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	c.Request.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+	in := &In{}
+	if err := CookieBindHook("c", nil)(c, in); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, &In{Session: "abc"}, in)
+}
+
+func TestFormBindHook(t *testing.T) {
+	hook := tonic.GetBindHook()
+	defer tonic.SetBindHook(hook)
+
+	tonic.SetBindHook(FormBindHook(hook))
+
+	type In struct {
+		ID    string                `path:"id"`
+		Title string                `form:"title" validate:"required"`
+		File  *multipart.FileHeader `form:"file" validate:"required"`
+	}
+	fizz := New()
+	fizz.POST("/upload/:id", nil, tonic.Handler(func(c *gin.Context, in *In) (string, error) {
+		f, err := in.File.Open()
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s-%s-%s-%s", in.ID, in.Title, in.File.Filename, b), nil
+	}, http.StatusOK))
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	if err := w.WriteField("title", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := w.CreateFormFile("file", "hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte("world")); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	req, err := http.NewRequest("POST", "/upload/42", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	recorder := httptest.NewRecorder()
+	fizz.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"42-hello-hello.txt-world"`, recorder.Body.String())
+
+	// Missing file.
+	req, err = http.NewRequest("POST", "/upload/42", strings.NewReader("title=hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	recorder = httptest.NewRecorder()
+	fizz.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// The request body is documented.
+	op := fizz.Generator().Operation("/upload/{id}", "POST")
+	if assert.NotNil(t, op) && assert.NotNil(t, op.RequestBody) {
+		assert.Contains(t, op.RequestBody.Content, "multipart/form-data")
+	}
+}
+
+func TestNegotiatedRenderHook(t *testing.T) {
+	hook := tonic.GetRenderHook()
+	defer tonic.SetRenderHook(hook, "")
+
+	type testFruit struct {
+		Name   string `json:"name" xml:"name"`
+		Origin string `json:"origin" xml:"origin"`
+	}
+	tonic.SetRenderHook(NegotiatedRenderHook(map[string]Renderer{
+		"text/csv": func(c *gin.Context, status int, payload interface{}) {
+			f := payload.(*testFruit)
+			c.String(status, "%s,%s", f.Name, f.Origin)
+		},
+	}), "")
+
+	fizz := New()
+	fizz.GET("/fruit", []OperationOption{
+		Produces("application/json", "application/xml", "text/csv"),
+	}, tonic.Handler(func(c *gin.Context) (*testFruit, error) {
+		return &testFruit{Name: "banana", Origin: "Ecuador"}, nil
+	}, http.StatusOK))
+
+	for accept, body := range map[string]string{
+		"":                                `{"name":"banana","origin":"Ecuador"}`,
+		"application/json":                `{"name":"banana","origin":"Ecuador"}`,
+		"application/xml":                 `<testFruit><name>banana</name><origin>Ecuador</origin></testFruit>`,
+		"text/csv, application/xml;q=0.9": `banana,Ecuador`,
+		"image/png":                       `{"name":"banana","origin":"Ecuador"}`,
+	} {
+		req, err := http.NewRequest("GET", "/fruit", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		recorder := httptest.NewRecorder()
+		fizz.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code, accept)
+		assert.Equal(t, body, recorder.Body.String(), accept)
+	}
+}
+
+func TestContentTypeBindHook(t *testing.T) {
+	hook := tonic.GetBindHook()
+	defer tonic.SetBindHook(hook)
+
+	tonic.SetBindHook(ContentTypeBindHook(hook))
+
+	type In struct {
+		Name  string `json:"name" xml:"name" validate:"required"`
+		Color string `json:"color" xml:"colour"`
+	}
+	fizz := New()
+	fizz.POST("/fruits", []OperationOption{
+		Consumes("application/json", "application/merge-patch+json", "application/xml"),
+	}, tonic.Handler(func(c *gin.Context, in *In) (string, error) {
+		return in.Name + "-" + in.Color, nil
+	}, http.StatusOK))
+
+	for _, tt := range []struct {
+		ct, body string
+		code     int
+	}{
+		{"application/json", `{"name":"apple","color":"red"}`, http.StatusOK},
+		{"application/merge-patch+json", `{"name":"apple","color":"red"}`, http.StatusOK},
+		{"application/xml", `<In><name>apple</name><colour>red</colour></In>`, http.StatusOK},
+		{"application/x-yaml", "name: apple", http.StatusBadRequest},
+		{"application/xml", `<In><colour>red</colour></In>`, http.StatusBadRequest},
+	} {
+		req, err := http.NewRequest("POST", "/fruits", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", tt.ct)
+
+		recorder := httptest.NewRecorder()
+		fizz.ServeHTTP(recorder, req)
+
+		assert.Equal(t, tt.code, recorder.Code, tt.ct)
+		if tt.code == http.StatusOK {
+			assert.Equal(t, `"apple-red"`, recorder.Body.String(), tt.ct)
+		}
+	}
+}
+
 func diffJSON(a, b []byte) (bool, error) {
 	var j1, j2 interface{}
 	if err := json.Unmarshal(a, &j1); err != nil {
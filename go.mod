@@ -1,6 +1,6 @@
 module github.com/wI2L/fizz
 
-go 1.12
+go 1.16
 
 require (
 	github.com/Pallinder/go-randomdata v1.2.0
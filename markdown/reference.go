@@ -0,0 +1,642 @@
+package markdown
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+// defaultMaxDepth is the default maximum depth of
+// the nested properties flattened in schema tables.
+const defaultMaxDepth = 5
+
+// Order of the operations of a path.
+var methods = []string{"GET", "PUT", "POST", "PATCH", "DELETE", "HEAD", "OPTIONS", "TRACE"}
+
+// Options represents the options of the
+// rendering of an OpenAPI document.
+type Options struct {
+	// Title of the document. Default to the
+	// title of the API informations.
+	Title string
+
+	// MaxDepth is the maximum depth of the nested
+	// properties flattened in the schema tables.
+	// Default to 5.
+	MaxDepth int
+
+	// IncludeInternal indicates whether the
+	// operations marked as internal are rendered.
+	IncludeInternal bool
+}
+
+// operation represents an operation
+// with its path and method.
+type operation struct {
+	*openapi.Operation
+	path   string
+	method string
+	anchor string
+}
+
+func (op *operation) title() string {
+	return op.method + " " + op.path
+}
+
+// reference represents the state of the
+// rendering of an OpenAPI document.
+type reference struct {
+	api     *openapi.OpenAPI
+	opts    *Options
+	b       *Builder
+	anchors map[string]bool
+}
+
+// FromOpenAPI renders the OpenAPI document api
+// as a Markdown API reference, with a table of
+// contents of the operations grouped by tag.
+// A nil document is rendered as an empty one.
+func FromOpenAPI(api *openapi.OpenAPI, opts *Options) string {
+	if api == nil {
+		api = &openapi.OpenAPI{}
+	}
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxDepth <= 0 {
+		o.MaxDepth = defaultMaxDepth
+	}
+	r := &reference{
+		api:     api,
+		opts:    &o,
+		b:       &Builder{},
+		anchors: make(map[string]bool),
+	}
+	r.render()
+
+	return r.b.String()
+}
+
+func (r *reference) render() {
+	b := r.b
+
+	title := r.opts.Title
+	if title == "" && r.api.Info != nil {
+		title = r.api.Info.Title
+	}
+	if title != "" {
+		b.H1(title)
+	}
+	if info := r.api.Info; info != nil {
+		if info.Version != "" {
+			b.P("Version: " + b.InlineCode(info.Version))
+		}
+		if info.Description != "" {
+			b.P(info.Description)
+		}
+	}
+	if len(r.api.Servers) != 0 {
+		b.H2("Servers")
+		var list []interface{}
+		for _, s := range r.api.Servers {
+			item := b.InlineCode(s.URL)
+			if s.Description != "" {
+				item += " - " + s.Description
+			}
+			list = append(list, item)
+		}
+		b.BulletedList(list...)
+	}
+	tags, groups := r.groupOperations()
+
+	b.H2("Table of contents")
+	var toc []interface{}
+	for _, tag := range tags {
+		items := make([]interface{}, 0, len(groups[tag]))
+		for _, op := range groups[tag] {
+			op.anchor = r.uniqueAnchor(tag + " " + op.title())
+			item := b.Link("#"+op.anchor, b.InlineCode(op.title()))
+			if op.Summary != "" {
+				item += " - " + op.Summary
+			}
+			items = append(items, item)
+		}
+		toc = append(toc, b.Link("#"+anchor(tag), tag)+"\n"+b.Block().BulletedList(items...).String())
+	}
+	if r.hasSecuritySchemes() {
+		toc = append(toc, b.Link("#authentication", "Authentication"))
+	}
+	b.BulletedList(toc...)
+
+	for _, tag := range tags {
+		b.H2(tag)
+		if desc := r.tagDescription(tag); desc != "" {
+			b.P(desc)
+		}
+		for _, op := range groups[tag] {
+			r.renderOperation(op)
+		}
+	}
+	if r.hasSecuritySchemes() {
+		r.renderSecuritySchemes()
+	}
+}
+
+// groupOperations returns the operations grouped by
+// tag, with the list of tags in order of appearance.
+// The tags declared in the document come first, and the
+// operations without tag are grouped last.
+func (r *reference) groupOperations() ([]string, map[string][]*operation) {
+	const untagged = "Operations"
+
+	groups := make(map[string][]*operation)
+
+	paths := make([]string, 0, len(r.api.Paths))
+	for path := range r.api.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := r.api.Paths[path]
+		for _, m := range methods {
+			op := item.Operation(m)
+			if op == nil || (op.XInternal && !r.opts.IncludeInternal) {
+				continue
+			}
+			if len(op.Tags) == 0 {
+				groups[untagged] = append(groups[untagged], &operation{Operation: op, path: path, method: m})
+			}
+			// Each section has its own copy of the
+			// operation, with a distinct anchor.
+			for _, tag := range op.Tags {
+				groups[tag] = append(groups[tag], &operation{Operation: op, path: path, method: m})
+			}
+		}
+	}
+	var (
+		tags []string
+		seen = make(map[string]bool)
+	)
+	for _, t := range r.api.Tags {
+		if _, ok := groups[t.Name]; ok && !seen[t.Name] {
+			tags = append(tags, t.Name)
+			seen[t.Name] = true
+		}
+	}
+	var others []string
+	for tag := range groups {
+		if !seen[tag] && tag != untagged {
+			others = append(others, tag)
+		}
+	}
+	sort.Strings(others)
+	tags = append(tags, others...)
+
+	if _, ok := groups[untagged]; ok {
+		tags = append(tags, untagged)
+	}
+	return tags, groups
+}
+
+func (r *reference) tagDescription(name string) string {
+	for _, t := range r.api.Tags {
+		if t.Name == name {
+			return t.Description
+		}
+	}
+	return ""
+}
+
+func (r *reference) renderOperation(op *operation) {
+	b := r.b
+
+	b.P(fmt.Sprintf(`<a id="%s"></a>`, op.anchor))
+	b.H3(op.title())
+
+	if op.Deprecated {
+		b.Blockquote(b.Bold("Deprecated"))
+	}
+	if op.Summary != "" {
+		b.P(b.Bold(op.Summary))
+	}
+	if op.Description != "" {
+		b.P(op.Description)
+	}
+	if op.ID != "" {
+		b.P("Operation ID: " + b.InlineCode(op.ID))
+	}
+	r.renderSecurity(op)
+	r.renderParameters(op)
+
+	if rb := op.RequestBody; rb != nil {
+		b.H4("Request body")
+		if rb.Description != "" {
+			b.P(rb.Description)
+		}
+		if rb.Required {
+			b.P(b.Italic("Required"))
+		}
+		for _, mt := range openapi.SortedKeys(rb.Content) {
+			r.renderMediaType(mt, rb.Content[mt])
+		}
+	}
+	r.renderResponses(op)
+}
+
+func (r *reference) renderSecurity(op *operation) {
+	security := op.Security
+	if security == nil {
+		security = r.api.Security
+	}
+	if len(security) == 0 {
+		return
+	}
+	b := r.b
+
+	var list []interface{}
+	for _, req := range security {
+		if req == nil || len(*req) == 0 {
+			list = append(list, "None")
+			continue
+		}
+		var schemes []string
+		for _, name := range openapi.SortedKeys(*req) {
+			s := b.InlineCode(name)
+			if scopes := (*req)[name]; len(scopes) != 0 {
+				s += fmt.Sprintf(" (scopes: %s)", strings.Join(scopes, ", "))
+			}
+			schemes = append(schemes, s)
+		}
+		list = append(list, strings.Join(schemes, " and "))
+	}
+	b.H4("Security")
+	b.BulletedList(list...)
+}
+
+func (r *reference) renderParameters(op *operation) {
+	if len(op.Parameters) == 0 {
+		return
+	}
+	table := [][]string{{"Name", "In", "Type", "Required", "Description"}}
+
+	for _, por := range op.Parameters {
+		p := r.api.ResolveParameter(por)
+		if p == nil {
+			continue
+		}
+		s := r.api.ResolveSchema(p.Schema)
+		table = append(table, []string{
+			r.b.InlineCode(p.Name),
+			p.In,
+			escape(r.typeString(p.Schema)),
+			yesNo(p.Required),
+			escape(describe(p.Description, s, p.Deprecated)),
+		})
+	}
+	r.b.H4("Parameters")
+	r.b.Table(table, nil)
+}
+
+func (r *reference) renderResponses(op *operation) {
+	if len(op.Responses) == 0 {
+		return
+	}
+	b := r.b
+	codes := openapi.SortedKeys(op.Responses)
+
+	table := [][]string{{"Status", "Description"}}
+	for _, code := range codes {
+		var desc string
+		if resp := r.api.ResolveResponse(op.Responses[code]); resp != nil {
+			desc = resp.Description
+		}
+		table = append(table, []string{code, escape(desc)})
+	}
+	b.H4("Responses")
+	b.Table(table, nil)
+
+	for _, code := range codes {
+		resp := r.api.ResolveResponse(op.Responses[code])
+		if resp == nil || (len(resp.Headers) == 0 && len(resp.Content) == 0) {
+			continue
+		}
+		b.H5(code + " response")
+
+		if len(resp.Headers) != 0 {
+			headers := [][]string{{"Name", "Type", "Description"}}
+			for _, name := range openapi.SortedKeys(resp.Headers) {
+				h := r.api.ResolveHeader(resp.Headers[name])
+				if h == nil {
+					continue
+				}
+				headers = append(headers, []string{
+					b.InlineCode(name),
+					escape(r.typeString(h.Schema)),
+					escape(describe(h.Description, r.api.ResolveSchema(h.Schema), h.Deprecated)),
+				})
+			}
+			b.P("Headers:")
+			b.Table(headers, nil)
+		}
+		for _, mt := range openapi.SortedKeys(resp.Content) {
+			if mtor := resp.Content[mt]; mtor != nil && mtor.MediaType != nil {
+				r.renderMediaType(mt, mtor.MediaType)
+			}
+		}
+	}
+}
+
+func (r *reference) renderMediaType(name string, mt *openapi.MediaType) {
+	if mt == nil {
+		return
+	}
+	b := r.b
+	b.P("Content type: " + b.InlineCode(name))
+
+	if rows := r.schemaRows(mt.Schema); len(rows) != 0 {
+		table := [][]string{{"Name", "Type", "Required", "Description"}}
+		b.Table(append(table, rows...), nil)
+	} else if t := r.typeString(mt.Schema); t != "" {
+		b.P("Type: " + escape(t))
+	}
+	switch {
+	case mt.Example != nil:
+		r.renderExample("Example", mt.Example)
+	case len(mt.Examples) != 0:
+		for _, name := range openapi.SortedKeys(mt.Examples) {
+			e := mt.Examples[name]
+			if e == nil || e.Example == nil {
+				continue
+			}
+			title := "Example " + b.InlineCode(name)
+			if e.Summary != "" {
+				title += " - " + e.Summary
+			}
+			r.renderExample(title, e.Value)
+		}
+	default:
+		if s := r.api.ResolveSchema(mt.Schema); s != nil {
+			r.renderExample("Example", s.Example)
+		}
+	}
+}
+
+func (r *reference) renderExample(title string, v interface{}) {
+	if v == nil {
+		return
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return
+	}
+	r.b.P(title + ":")
+	r.b.Code(string(b), "json")
+}
+
+func (r *reference) renderSecuritySchemes() {
+	b := r.b
+	b.H2("Authentication")
+
+	table := [][]string{{"Name", "Type", "Description"}}
+	schemes := r.api.Components.SecuritySchemes
+
+	for _, name := range openapi.SortedKeys(schemes) {
+		s := schemes[name]
+		if s == nil || s.SecurityScheme == nil {
+			continue
+		}
+		typ := s.Type
+		switch {
+		case s.Scheme != "":
+			typ += fmt.Sprintf(" (%s)", s.Scheme)
+		case s.In != "":
+			typ += fmt.Sprintf(" (%s %s)", s.In, b.InlineCode(s.Name))
+		}
+		table = append(table, []string{
+			b.InlineCode(name),
+			escape(typ),
+			escape(s.Description),
+		})
+	}
+	b.Table(table, nil)
+}
+
+func (r *reference) hasSecuritySchemes() bool {
+	return r.api.Components != nil && len(r.api.Components.SecuritySchemes) != 0
+}
+
+// schemaRows returns the rows of the table that
+// describes the properties of the schema sor. The
+// nested properties are flattened with dotted names.
+func (r *reference) schemaRows(sor *openapi.SchemaOrRef) [][]string {
+	var rows [][]string
+	r.flatten(sor, "", 0, make(map[string]bool), &rows)
+
+	return rows
+}
+
+func (r *reference) flatten(sor *openapi.SchemaOrRef, prefix string, depth int, refs map[string]bool, rows *[][]string) {
+	if depth >= r.opts.MaxDepth {
+		return
+	}
+	// Don't expand recursive schemas.
+	if name := openapi.SchemaName(sor); name != "" {
+		if refs[name] {
+			return
+		}
+		refs[name] = true
+		defer delete(refs, name)
+	}
+	schema := r.api.ResolveSchema(sor)
+	if schema == nil {
+		return
+	}
+	if schema.Type == "array" {
+		r.flatten(schema.Items, prefix+"[]", depth, refs, rows)
+		return
+	}
+	for _, s := range schema.AllOf {
+		r.flatten(s, prefix, depth, refs, rows)
+	}
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	for _, name := range openapi.SortedKeys(schema.Properties) {
+		p := schema.Properties[name]
+		fullname := name
+		if prefix != "" {
+			fullname = prefix + "." + name
+		}
+		ps := r.api.ResolveSchema(p)
+
+		var (
+			desc       string
+			deprecated bool
+		)
+		if ps != nil {
+			desc, deprecated = ps.Description, ps.Deprecated
+		}
+		*rows = append(*rows, []string{
+			r.b.InlineCode(fullname),
+			escape(r.typeString(p)),
+			yesNo(required[name]),
+			escape(describe(desc, ps, deprecated)),
+		})
+		r.flatten(p, fullname, depth+1, refs, rows)
+	}
+}
+
+// typeString returns a human-readable
+// representation of the type of a schema.
+func (r *reference) typeString(sor *openapi.SchemaOrRef) string {
+	schema := r.api.ResolveSchema(sor)
+	if schema == nil {
+		return openapi.SchemaName(sor)
+	}
+	var t string
+
+	switch {
+	case len(schema.OneOf) != 0:
+		t = "one of " + r.typesString(schema.OneOf)
+	case len(schema.AnyOf) != 0:
+		t = "any of " + r.typesString(schema.AnyOf)
+	case len(schema.AllOf) != 0:
+		t = "all of " + r.typesString(schema.AllOf)
+	case schema.Type == "array":
+		t = "array of " + r.typeString(schema.Items)
+	case schema.Type == "object" && len(schema.Properties) == 0 && schema.AdditionalProperties != nil:
+		t = "map of " + r.typeString(schema.AdditionalProperties)
+	case schema.Type == "object" || schema.Type == "":
+		t = schema.Type
+		if name := openapi.SchemaName(sor); name != "" {
+			t = name
+		}
+	default:
+		t = schema.Type
+		if schema.Format != "" {
+			t += fmt.Sprintf(" (%s)", schema.Format)
+		}
+	}
+	if schema.Nullable {
+		t += ", nullable"
+	}
+	return t
+}
+
+func (r *reference) typesString(schemas []*openapi.SchemaOrRef) string {
+	types := make([]string, 0, len(schemas))
+	for _, s := range schemas {
+		types = append(types, r.typeString(s))
+	}
+	return strings.Join(types, ", ")
+}
+
+// describe returns the description of a value,
+// followed by the constraints of its schema.
+func describe(desc string, schema *openapi.Schema, deprecated bool) string {
+	var parts []string
+	if deprecated {
+		parts = append(parts, "**Deprecated**.")
+	}
+	if desc != "" {
+		parts = append(parts, desc)
+	}
+	if schema == nil {
+		return strings.Join(parts, " ")
+	}
+	if len(schema.Enum) != 0 {
+		values := make([]string, 0, len(schema.Enum))
+		for _, v := range schema.Enum {
+			values = append(values, fmt.Sprintf("`%v`", v))
+		}
+		parts = append(parts, fmt.Sprintf("Allowed values: %s.", strings.Join(values, ", ")))
+	}
+	if schema.Default != nil {
+		parts = append(parts, fmt.Sprintf("Default: `%v`.", schema.Default))
+	}
+	if schema.Minimum != nil {
+		if schema.ExclusiveMinimum {
+			parts = append(parts, fmt.Sprintf("Exclusive minimum: %v.", *schema.Minimum))
+		} else {
+			parts = append(parts, fmt.Sprintf("Minimum: %v.", *schema.Minimum))
+		}
+	}
+	if schema.Maximum != nil {
+		if schema.ExclusiveMaximum {
+			parts = append(parts, fmt.Sprintf("Exclusive maximum: %v.", *schema.Maximum))
+		} else {
+			parts = append(parts, fmt.Sprintf("Maximum: %v.", *schema.Maximum))
+		}
+	}
+	if schema.MultipleOf != nil {
+		parts = append(parts, fmt.Sprintf("Multiple of: %v.", *schema.MultipleOf))
+	}
+	if schema.MinLength != 0 {
+		parts = append(parts, fmt.Sprintf("Minimum length: %d.", schema.MinLength))
+	}
+	if schema.MaxLength != 0 {
+		parts = append(parts, fmt.Sprintf("Maximum length: %d.", schema.MaxLength))
+	}
+	if schema.Pattern != "" {
+		parts = append(parts, fmt.Sprintf("Pattern: `%s`.", schema.Pattern))
+	}
+	return strings.Join(parts, " ")
+}
+
+// anchor returns the anchor of the given header,
+// as generated by the GitHub and GitLab renderers.
+func anchor(header string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(header)) {
+		switch {
+		case r == ' ':
+			sb.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// uniqueAnchor returns the anchor of the given
+// text, suffixed with a number if it is already
+// used by another operation of the document.
+func (r *reference) uniqueAnchor(text string) string {
+	a := anchor(text)
+	id := a
+	for i := 1; r.anchors[id]; i++ {
+		id = fmt.Sprintf("%s-%d", a, i)
+	}
+	r.anchors[id] = true
+
+	return id
+}
+
+// cellReplacer replaces the characters that
+// would break the layout of a table cell.
+var cellReplacer = strings.NewReplacer(
+	"|", `\|`,
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+// escape escapes the characters of s that
+// would break the layout of a table cell.
+func escape(s string) string {
+	return cellReplacer.Replace(s)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,136 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/loopfz/gadgeto/tonic"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+type (
+	address struct {
+		City string `json:"city" validate:"required" description:"Name of the city"`
+	}
+	user struct {
+		Name    string   `json:"name" validate:"required" example:"Gopher"`
+		Role    string   `json:"role" enum:"admin,guest"`
+		Address *address `json:"address"`
+		Tags    []string `json:"tags"`
+	}
+	apiError struct {
+		Message string `json:"message"`
+	}
+	getUserInput struct {
+		ID     int    `path:"id" description:"ID of the user"`
+		Fields string `query:"fields" description:"Comma-separated\nlist of fields"`
+	}
+)
+
+// TestFromOpenAPI tests that an OpenAPI document
+// is rendered as a Markdown API reference.
+func TestFromOpenAPI(t *testing.T) {
+	g, err := openapi.NewGenerator(&openapi.SpecGenConfig{
+		ValidatorTag:      tonic.ValidationTag,
+		PathLocationTag:   tonic.PathTag,
+		QueryLocationTag:  tonic.QueryTag,
+		HeaderLocationTag: tonic.HeaderTag,
+		EnumTag:           tonic.EnumTag,
+		DefaultTag:        tonic.DefaultTag,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.UseFullSchemaNames(false)
+	g.SetInfo(&openapi.Info{Title: "Users API", Version: "1.0.0"})
+	g.AddTag("Users", "Manage the users")
+	g.SetSecuritySchemes(map[string]*openapi.SecuritySchemeOrRef{
+		"apiKey": {SecurityScheme: &openapi.SecurityScheme{
+			Type: "apiKey",
+			In:   "header",
+			Name: "X-API-Key",
+		}},
+	})
+	_, err = g.AddOperation("/users/:id", "GET", "Users",
+		reflect.TypeOf(&getUserInput{}),
+		reflect.TypeOf(&user{}),
+		&openapi.OperationInfo{
+			ID:         "GetUser",
+			Summary:    "Get a user",
+			StatusCode: 200,
+			Security: []*openapi.SecurityRequirement{
+				{"apiKey": []string{}},
+			},
+			Responses: []*openapi.OperationResponse{{
+				Code:        "404",
+				Description: "User not found",
+				Model:       apiError{},
+				Example:     apiError{Message: "user not found"},
+			}},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{}
+	md := FromOpenAPI(g.API(), opts)
+
+	// The options of the caller are left untouched.
+	assert.Zero(t, opts.MaxDepth)
+
+	for _, s := range []string{
+		"# Users API",
+		"* [Users](#users)\n  * [`GET /users/{id}`](#users-get-usersid) - Get a user",
+		"<a id=\"users-get-usersid\"></a>\n\n### GET /users/{id}",
+		"Operation ID: `GetUser`",
+		"#### Security\n\n* `apiKey`",
+		"| `id`     | path  | integer (int32) | yes      | ID of the user                    |",
+		"| `address.city` | string          | yes      | Name of the city                  |",
+		"| `role`         | string          | no       | Allowed values: `admin`, `guest`. |",
+		"| `tags`         | array of string | no       |                                   |",
+		"| `fields` | query | string          | no       | Comma-separated<br>list of fields |",
+		"\"message\": \"user not found\"",
+		"## Authentication",
+		"| `apiKey` | apiKey (header `X-API-Key`) |",
+	} {
+		assert.True(t, strings.Contains(md, s), "missing %q", s)
+	}
+	// Only the documented examples are rendered.
+	assert.NotContains(t, md, `"name": "Gopher"`)
+}
+
+// TestFromOpenAPIAnchors tests that an operation rendered
+// in several sections has a distinct anchor in each of them.
+func TestFromOpenAPIAnchors(t *testing.T) {
+	api := &openapi.OpenAPI{
+		Paths: openapi.Paths{
+			"/pets": &openapi.PathItem{
+				GET: &openapi.Operation{Tags: []string{"Pets", "Animals"}},
+			},
+			"/pets/": &openapi.PathItem{
+				GET: &openapi.Operation{Tags: []string{"Pets"}},
+			},
+		},
+	}
+	md := FromOpenAPI(api, nil)
+
+	for _, s := range []string{
+		"* [Animals](#animals)\n  * [`GET /pets`](#animals-get-pets)",
+		"* [Pets](#pets)\n  * [`GET /pets`](#pets-get-pets)\n  * [`GET /pets/`](#pets-get-pets-1)",
+		`<a id="animals-get-pets"></a>`,
+		`<a id="pets-get-pets"></a>`,
+		`<a id="pets-get-pets-1"></a>`,
+	} {
+		assert.True(t, strings.Contains(md, s), "missing %q", s)
+	}
+}
+
+// TestFromOpenAPINil tests that a nil document
+// is rendered as an empty one.
+func TestFromOpenAPINil(t *testing.T) {
+	assert.Equal(t, FromOpenAPI(&openapi.OpenAPI{}, nil), FromOpenAPI(nil, nil))
+	assert.True(t, strings.HasPrefix(FromOpenAPI(nil, &Options{Title: "API"}), "# API\n"))
+}
@@ -0,0 +1,551 @@
+// Package mock provides an http.Handler that serves mock
+// responses for the operations of an OpenAPI specification,
+// such as the one generated by Fizz, using the examples of
+// the responses, or data synthesized from their schemas.
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+// PreferHeader is the name of the request header that
+// can be used to select the status code and the named
+// example of the mock response, such as code=404 or
+// example=empty.
+const PreferHeader = "Prefer"
+
+// Handler serves the mock responses of the
+// operations of an OpenAPI specification.
+type Handler struct {
+	api    *openapi.OpenAPI
+	routes []*route
+}
+
+type route struct {
+	path     string
+	segments []string
+	item     *openapi.PathItem
+}
+
+// New returns a new Handler that routes the requests
+// using the paths of the specification api.
+func New(api *openapi.OpenAPI) *Handler {
+	h := &Handler{api: api}
+
+	for path, item := range api.Paths {
+		if item == nil {
+			continue
+		}
+		h.routes = append(h.routes, &route{
+			path:     path,
+			segments: splitPath(path),
+			item:     item,
+		})
+	}
+	// The concrete paths are matched before the
+	// templated paths that have the same length,
+	// as stated by the specification.
+	sort.Slice(h.routes, func(i, j int) bool {
+		ri, rj := h.routes[i], h.routes[j]
+		if ci, cj := ri.literals(), rj.literals(); ci != cj {
+			return ci > cj
+		}
+		return ri.path < rj.path
+	})
+	return h
+}
+
+// Load parses an OpenAPI 3.0 or 3.1 specification
+// marshaled in JSON or YAML. The vendor extensions
+// of the objects of the specification are preserved.
+func Load(b []byte) (*openapi.OpenAPI, error) {
+	var api openapi.OpenAPI
+
+	if err := json.Unmarshal(b, &api); err == nil {
+		return &api, nil
+	}
+	// The types of the specification are not
+	// compatible with the YAML decoder, so the
+	// document is converted to JSON first.
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(convertYAML(v))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &api); err != nil {
+		return nil, err
+	}
+	return &api, nil
+}
+
+// ServeHTTP implements http.Handler for Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt := h.match(r.URL.Path)
+	if rt == nil {
+		http.NotFound(w, r)
+		return
+	}
+	op := operation(rt.item, r.Method)
+	if op == nil {
+		w.Header().Set("Allow", strings.Join(methods(rt.item), ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	prefs := preferences(r.Header.Get(PreferHeader))
+
+	code, resp := h.response(op, prefs["code"])
+	if resp == nil {
+		w.WriteHeader(code)
+		return
+	}
+	for name, hor := range resp.Headers {
+		if hdr := h.api.ResolveHeader(hor); hdr != nil && hdr.Schema != nil {
+			if v := openapi.GenerateExample(h.api, hdr.Schema); v != nil {
+				w.Header().Set(name, fmt.Sprint(v))
+			}
+		}
+	}
+	offers := mediaTypes(resp.Content)
+	if len(offers) == 0 {
+		w.WriteHeader(code)
+		return
+	}
+	offers = negotiate(offers, r.Header.Get("Accept"))
+	if len(offers) == 0 {
+		http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return
+	}
+	// The media types whose example cannot be
+	// encoded are skipped.
+	var (
+		mt  string
+		b   []byte
+		err error
+	)
+	for _, mt = range offers {
+		media := resp.Content[mt].MediaType
+		b, err = marshal(mt, xmlName(media.Schema), h.example(media, prefs["example"]))
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Set("Content-Type", mt)
+	w.WriteHeader(code)
+
+	if r.Method != http.MethodHead {
+		w.Write(b)
+	}
+}
+
+// match returns the route that matches the path p.
+func (h *Handler) match(p string) *route {
+	segments := splitPath(p)
+
+	for _, rt := range h.routes {
+		if rt.match(segments) {
+			return rt
+		}
+	}
+	return nil
+}
+
+// response returns the status code and the response of
+// the operation. The preferred code is used if documented,
+// otherwise the first success response is selected, then
+// the default response.
+func (h *Handler) response(op *openapi.Operation, prefer string) (int, *openapi.Response) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	selected := ""
+	if _, ok := op.Responses[prefer]; ok {
+		selected = prefer
+	} else {
+		for _, code := range codes {
+			if strings.HasPrefix(code, "2") {
+				selected = code
+				break
+			}
+		}
+		if selected == "" && len(codes) != 0 {
+			selected = codes[0]
+			if _, ok := op.Responses["default"]; ok {
+				selected = "default"
+			}
+		}
+	}
+	if selected == "" {
+		return http.StatusOK, nil
+	}
+	return statusCode(selected), h.api.ResolveResponse(op.Responses[selected])
+}
+
+// example returns the example of the media type with the
+// preferred name, or its first example, or a value that
+// is synthesized from its schema.
+func (h *Handler) example(media *openapi.MediaType, prefer string) interface{} {
+	if media.Example != nil {
+		return media.Example
+	}
+	if len(media.Examples) != 0 {
+		names := make([]string, 0, len(media.Examples))
+		for name := range media.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if _, ok := media.Examples[prefer]; ok {
+			names = append([]string{prefer}, names...)
+		}
+		for _, name := range names {
+			if ex := h.resolveExample(media.Examples[name]); ex != nil && ex.Value != nil {
+				return ex.Value
+			}
+		}
+	}
+	return openapi.GenerateExample(h.api, media.Schema)
+}
+
+// resolveExample returns either the inlined example
+// in eor or the one referenced in the API components.
+func (h *Handler) resolveExample(eor *openapi.ExampleOrRef) *openapi.Example {
+	if eor == nil {
+		return nil
+	}
+	if eor.Example != nil {
+		return eor.Example
+	}
+	if eor.Reference != nil && h.api.Components != nil {
+		name := strings.TrimPrefix(eor.Ref, "#/components/examples/")
+		return h.resolveExample(h.api.Components.Examples[name])
+	}
+	return nil
+}
+
+// literals returns the number of segments
+// of the route that are not templated.
+func (rt *route) literals() int {
+	n := 0
+	for _, s := range rt.segments {
+		if !isParam(s) {
+			n++
+		}
+	}
+	return n
+}
+
+// match returns whether the segments of
+// a request path match those of the route.
+func (rt *route) match(segments []string) bool {
+	if len(segments) != len(rt.segments) {
+		return false
+	}
+	for i, s := range rt.segments {
+		if isParam(s) {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if s != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isParam(s string) bool {
+	return strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
+
+// operation returns the operation of the
+// path item for the given method.
+func operation(item *openapi.PathItem, method string) *openapi.Operation {
+	switch method {
+	case http.MethodGet:
+		return item.GET
+	case http.MethodPut:
+		return item.PUT
+	case http.MethodPost:
+		return item.POST
+	case http.MethodDelete:
+		return item.DELETE
+	case http.MethodOptions:
+		return item.OPTIONS
+	case http.MethodHead:
+		if item.HEAD == nil {
+			return item.GET
+		}
+		return item.HEAD
+	case http.MethodPatch:
+		return item.PATCH
+	case http.MethodTrace:
+		return item.TRACE
+	}
+	return nil
+}
+
+// methods returns the methods of the
+// operations of the path item.
+func methods(item *openapi.PathItem) []string {
+	var ms []string
+	for _, m := range []string{
+		http.MethodGet,
+		http.MethodPut,
+		http.MethodPost,
+		http.MethodDelete,
+		http.MethodOptions,
+		http.MethodHead,
+		http.MethodPatch,
+		http.MethodTrace,
+	} {
+		if operation(item, m) != nil {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+// statusCode converts the code of a response of the
+// specification to an HTTP status code. The default
+// response and the ranges, such as 2XX, use the first
+// code of their class.
+func statusCode(code string) int {
+	if code == "default" {
+		return http.StatusOK
+	}
+	if len(code) == 3 && strings.HasSuffix(strings.ToUpper(code), "XX") {
+		code = code[:1] + "00"
+	}
+	if c, err := strconv.Atoi(code); err == nil {
+		return c
+	}
+	return http.StatusOK
+}
+
+// preferences parses the values of a Prefer header,
+// such as code=404, example=empty.
+func preferences(header string) map[string]string {
+	prefs := make(map[string]string)
+
+	for _, p := range strings.FieldsFunc(header, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' '
+	}) {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			prefs[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return prefs
+}
+
+// mediaTypes returns the media types of the content,
+// JSON first, then the others in alphabetical order.
+func mediaTypes(content map[string]*openapi.MediaTypeOrRef) []string {
+	offers := make([]string, 0, len(content))
+	for mt, mtor := range content {
+		if mtor != nil && mtor.MediaType != nil {
+			offers = append(offers, mt)
+		}
+	}
+	sort.Slice(offers, func(i, j int) bool {
+		ji, jj := isJSON(offers[i]), isJSON(offers[j])
+		if ji != jj {
+			return ji
+		}
+		return offers[i] < offers[j]
+	})
+	return offers
+}
+
+// negotiate returns the offers that match the Accept
+// header of the request, by order of preference. All
+// the offers are returned if the header is empty.
+func negotiate(offers []string, accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return offers
+	}
+	var (
+		accepted []string
+		seen     = make(map[string]bool)
+	)
+	for _, a := range strings.Split(accept, ",") {
+		a = baseMediaType(a)
+		if a == "" {
+			continue
+		}
+		for _, o := range offers {
+			if seen[o] {
+				continue
+			}
+			if a == "*/*" || a == baseMediaType(o) || (strings.HasSuffix(a, "/*") && strings.HasPrefix(o, strings.TrimSuffix(a, "*"))) {
+				accepted = append(accepted, o)
+				seen[o] = true
+			}
+		}
+	}
+	return accepted
+}
+
+// baseMediaType returns the media type mt
+// without its parameters.
+func baseMediaType(mt string) string {
+	return strings.TrimSpace(strings.SplitN(mt, ";", 2)[0])
+}
+
+func isJSON(mt string) bool {
+	mt = baseMediaType(mt)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func isYAML(mt string) bool {
+	return strings.Contains(baseMediaType(mt), "yaml")
+}
+
+func isXML(mt string) bool {
+	mt = baseMediaType(mt)
+	return mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
+
+// marshal encodes the value v for the media type mt.
+// The strings are written as is for the media types
+// other than JSON and YAML, and the other values are
+// encoded in XML as an element with the given name.
+// An error is returned if the value cannot be encoded
+// for the media type, such as an object for text.
+func marshal(mt, name string, v interface{}) ([]byte, error) {
+	switch {
+	case isJSON(mt):
+		return json.Marshal(v)
+	case isYAML(mt):
+		return yaml.Marshal(v)
+	}
+	if s, ok := v.(string); ok {
+		return []byte(s), nil
+	}
+	if isXML(mt) {
+		return marshalXML(name, v)
+	}
+	return nil, fmt.Errorf("cannot encode a value of type %T as %s", v, mt)
+}
+
+// xmlName returns the name of the root element of the
+// XML representation of the values of the schema sor,
+// which is the name of the schema it references.
+func xmlName(sor *openapi.SchemaOrRef) string {
+	if name := openapi.SchemaName(sor); name != "" {
+		return name
+	}
+	return "response"
+}
+
+// marshalXML encodes the value v in XML, as an element
+// with the given name. The properties of the objects are
+// encoded as child elements, and the items of the arrays
+// as repeated elements.
+func marshalXML(name string, v interface{}) ([]byte, error) {
+	// Convert the value to its JSON
+	// representation, such as a map
+	// for the structs.
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	e := xml.NewEncoder(buf)
+
+	// The items of a root array are
+	// wrapped in a single element.
+	if a, ok := v.([]interface{}); ok {
+		v = map[string]interface{}{"item": a}
+	}
+	if err := encodeXML(e, name, v); err != nil {
+		return nil, err
+	}
+	if err := e.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXML(e *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	switch t := v.(type) {
+	case []interface{}:
+		for _, item := range t {
+			if err := encodeXML(e, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := encodeXML(e, k, t[k]); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	case nil:
+		return e.EncodeElement("", start)
+	case float64:
+		return e.EncodeElement(strconv.FormatFloat(t, 'f', -1, 64), start)
+	default:
+		return e.EncodeElement(fmt.Sprint(t), start)
+	}
+}
+
+// convertYAML converts the maps decoded by the YAML
+// decoder, that use keys of any type, to maps with
+// string keys that can be marshaled in JSON.
+func convertYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[fmt.Sprint(k)] = convertYAML(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range t {
+			t[i] = convertYAML(v)
+		}
+	}
+	return v
+}
@@ -0,0 +1,278 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+type (
+	fruit struct {
+		Name    string  `json:"name" validate:"required"`
+		Color   string  `json:"color" enum:"red,green,yellow"`
+		Origin  string  `json:"origin" format:"uri"`
+		Price   float64 `json:"price" validate:"gte=2"`
+		Quality int     `json:"quality" validate:"lte=-3"`
+	}
+	getFruitInput struct {
+		Name string `path:"name"`
+	}
+	apiError struct {
+		Message string `json:"message"`
+	}
+)
+
+func newSpec(t *testing.T) *openapi.OpenAPI {
+	gen, err := openapi.NewGenerator(&openapi.SpecGenConfig{
+		ValidatorTag:     "validate",
+		PathLocationTag:  "path",
+		QueryLocationTag: "query",
+		EnumTag:          "enum",
+		DefaultTag:       "default",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen.UseFullSchemaNames(false)
+
+	in, out := reflect.TypeOf(&getFruitInput{}), reflect.TypeOf(&fruit{})
+
+	for _, op := range []struct {
+		path, method string
+		in, out      reflect.Type
+		info         *openapi.OperationInfo
+	}{
+		{"/fruits/{name}", "GET", in, out, &openapi.OperationInfo{
+			ID:         "GetFruit",
+			StatusCode: 200,
+			Responses: []*openapi.OperationResponse{{
+				Code:    "404",
+				Model:   &apiError{},
+				Example: &apiError{Message: "fruit not found"},
+			}},
+		}},
+		{"/fruits/{name}", "DELETE", in, nil, &openapi.OperationInfo{
+			ID:         "DeleteFruit",
+			StatusCode: 204,
+		}},
+		{"/fruits/search", "GET", nil, reflect.TypeOf([]*fruit{}), &openapi.OperationInfo{
+			ID:         "SearchFruits",
+			StatusCode: 200,
+			Responses: []*openapi.OperationResponse{{
+				Code:  "400",
+				Model: &apiError{},
+				Examples: map[string]interface{}{
+					"empty":   &apiError{Message: "empty query"},
+					"invalid": &apiError{Message: "invalid query"},
+				},
+			}},
+		}},
+	} {
+		if _, err := gen.AddOperation(op.path, op.method, "", op.in, op.out, op.info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return gen.API()
+}
+
+// TestHandler tests that the mock handler routes the
+// requests and serves the examples of the responses or
+// synthesized data.
+func TestHandler(t *testing.T) {
+	api := newSpec(t)
+
+	b, err := yaml.Marshal(api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = json.Marshal(api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadedJSON, err := Load(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, api := range map[string]*openapi.OpenAPI{
+		"live": api,
+		"yaml": loaded,
+		"json": loadedJSON,
+	} {
+		h := New(api)
+
+		// Synthesized data.
+		code, body := serve(h, "GET", "/fruits/apple", "")
+		assert.Equal(t, http.StatusOK, code, name)
+		assert.JSONEq(t, `{
+			"name": "string",
+			"color": "red",
+			"origin": "https://example.com",
+			"price": 2,
+			"quality": -3
+		}`, body, name)
+
+		// The concrete paths are matched first.
+		code, body = serve(h, "GET", "/fruits/search", "")
+		assert.Equal(t, http.StatusOK, code, name)
+		assert.JSONEq(t, `[{
+			"name": "string",
+			"color": "red",
+			"origin": "https://example.com",
+			"price": 2,
+			"quality": -3
+		}]`, body, name)
+
+		// Examples of the responses.
+		code, body = serve(h, "GET", "/fruits/apple", "code=404")
+		assert.Equal(t, http.StatusNotFound, code, name)
+		assert.JSONEq(t, `{"message":"fruit not found"}`, body, name)
+
+		code, body = serve(h, "GET", "/fruits/search", "code=400")
+		assert.Equal(t, http.StatusBadRequest, code, name)
+		assert.JSONEq(t, `{"message":"empty query"}`, body, name)
+
+		code, body = serve(h, "GET", "/fruits/search", "code=400, example=invalid")
+		assert.Equal(t, http.StatusBadRequest, code, name)
+		assert.JSONEq(t, `{"message":"invalid query"}`, body, name)
+
+		// No content.
+		code, body = serve(h, "DELETE", "/fruits/apple", "")
+		assert.Equal(t, http.StatusNoContent, code, name)
+		assert.Empty(t, body, name)
+
+		// Unknown paths and methods.
+		code, _ = serve(h, "GET", "/vegetables", "")
+		assert.Equal(t, http.StatusNotFound, code, name)
+
+		code, _ = serve(h, "POST", "/fruits/apple", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, code, name)
+	}
+}
+
+func serve(h http.Handler, method, path, prefer string) (int, string) {
+	req := httptest.NewRequest(method, path, nil)
+	if prefer != "" {
+		req.Header.Set(PreferHeader, prefer)
+	}
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	return recorder.Code, recorder.Body.String()
+}
+
+// TestLoad31 tests that an OpenAPI 3.1 specification
+// is loaded with its vendor extensions, and that the
+// responses are encoded for the negotiated media type.
+func TestLoad31(t *testing.T) {
+	api, err := Load([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Pets", "version": "1.0", "x-audience": "public"},
+		"paths": {
+			"/pets/{id}": {
+				"get": {
+					"responses": {
+						"200": {
+							"description": "OK",
+							"content": {
+								"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}},
+								"application/xml": {
+									"schema": {"$ref": "#/components/schemas/Pet"},
+									"example": "<pet><id>1</id></pet>"
+								},
+								"application/vnd.pet+xml": {"schema": {"$ref": "#/components/schemas/Pet"}},
+								"text/csv": {"schema": {"$ref": "#/components/schemas/Pet"}}
+							}
+						}
+					}
+				}
+			}
+		},
+		"components": {
+			"schemas": {
+				"Pet": {
+					"type": "object",
+					"required": ["id"],
+					"properties": {
+						"id": {"type": ["integer", "null"], "exclusiveMinimum": 10},
+						"name": {"type": "string", "examples": ["Rex"]}
+					}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "public", api.Info.Extensions["x-audience"])
+
+	id := api.Components.Schemas["Pet"].Properties["id"].Schema
+	assert.Equal(t, "integer", id.Type)
+	assert.True(t, id.Nullable)
+	assert.True(t, id.ExclusiveMinimum)
+	if assert.NotNil(t, id.Minimum) {
+		assert.Equal(t, float64(10), *id.Minimum)
+	}
+	h := New(api)
+
+	for _, tt := range []struct {
+		accept string
+		code   int
+		ct     string
+		body   string
+	}{
+		{"", http.StatusOK, "application/json", `{"id":11,"name":"Rex"}`},
+		{"application/xml", http.StatusOK, "application/xml", "<pet><id>1</id></pet>"},
+		{"text/csv, application/json;q=0.5", http.StatusOK, "application/json", `{"id":11,"name":"Rex"}`},
+		{"application/vnd.pet+xml", http.StatusOK, "application/vnd.pet+xml", "<Pet><id>11</id><name>Rex</name></Pet>"},
+		{"text/csv", http.StatusNotAcceptable, "", ""},
+		{"image/png", http.StatusNotAcceptable, "", ""},
+	} {
+		req := httptest.NewRequest("GET", "/pets/1", nil)
+		req.Header.Set("Accept", tt.accept)
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, req)
+
+		assert.Equal(t, tt.code, recorder.Code, tt.accept)
+		if tt.code != http.StatusOK {
+			continue
+		}
+		assert.Equal(t, tt.ct, recorder.Header().Get("Content-Type"), tt.accept)
+		if tt.ct == "application/json" {
+			assert.JSONEq(t, tt.body, recorder.Body.String(), tt.accept)
+		} else {
+			assert.Equal(t, tt.body, recorder.Body.String(), tt.accept)
+		}
+	}
+}
+
+// TestMarshalXML tests that the objects and arrays
+// are encoded in XML as elements.
+func TestMarshalXML(t *testing.T) {
+	for _, tt := range []struct {
+		v   interface{}
+		xml string
+	}{
+		{map[string]interface{}{"id": 1.5, "tags": []interface{}{"a", "b"}, "owner": nil}, "<Pet><id>1.5</id><owner></owner><tags>a</tags><tags>b</tags></Pet>"},
+		{[]interface{}{map[string]interface{}{"id": 1}}, "<Pet><item><id>1</id></item></Pet>"},
+		{struct {
+			Name string `json:"name"`
+		}{"Rex"}, "<Pet><name>Rex</name></Pet>"},
+	} {
+		b, err := marshal("application/xml", "Pet", tt.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tt.xml, string(b))
+	}
+}
@@ -0,0 +1,691 @@
+// Package diff compares two OpenAPI documents and
+// reports their differences as a list of changes
+// classified as breaking or non-breaking.
+package diff
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wI2L/fizz/markdown"
+	"github.com/wI2L/fizz/openapi"
+)
+
+// Kind represents the kind of a change.
+type Kind string
+
+// Kinds of changes.
+const (
+	OperationAdded      Kind = "operation-added"
+	OperationRemoved    Kind = "operation-removed"
+	ParameterAdded      Kind = "parameter-added"
+	ParameterRemoved    Kind = "parameter-removed"
+	ParameterRequired   Kind = "parameter-became-required"
+	ParameterOptional   Kind = "parameter-became-optional"
+	RequestBodyAdded    Kind = "request-body-added"
+	RequestBodyRemoved  Kind = "request-body-removed"
+	RequestBodyRequired Kind = "request-body-became-required"
+	RequestBodyOptional Kind = "request-body-became-optional"
+	MediaTypeAdded      Kind = "media-type-added"
+	MediaTypeRemoved    Kind = "media-type-removed"
+	ResponseAdded       Kind = "response-added"
+	ResponseRemoved     Kind = "response-removed"
+	HeaderAdded         Kind = "header-added"
+	HeaderRemoved       Kind = "header-removed"
+	HeaderRequired      Kind = "header-became-required"
+	HeaderOptional      Kind = "header-became-optional"
+	PropertyAdded       Kind = "property-added"
+	PropertyRemoved     Kind = "property-removed"
+	PropertyRequired    Kind = "property-became-required"
+	PropertyOptional    Kind = "property-became-optional"
+	TypeChanged         Kind = "type-changed"
+	NullableChanged     Kind = "nullable-changed"
+	EnumNarrowed        Kind = "enum-narrowed"
+	EnumWidened         Kind = "enum-widened"
+	SecurityAdded       Kind = "security-added"
+	SecurityRemoved     Kind = "security-removed"
+	SubschemaAdded      Kind = "subschema-added"
+	SubschemaRemoved    Kind = "subschema-removed"
+)
+
+// Order of the operations of a path.
+var methods = []string{"GET", "PUT", "POST", "PATCH", "DELETE", "HEAD", "OPTIONS", "TRACE"}
+
+// Change represents a single difference between
+// two versions of an OpenAPI document.
+type Change struct {
+	Kind     Kind   `json:"kind"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+	Breaking bool   `json:"breaking"`
+}
+
+// String implements fmt.Stringer for Change.
+func (c *Change) String() string {
+	s := fmt.Sprintf("%s %s", c.Method, c.Path)
+	if c.Location != "" {
+		s += " " + c.Location
+	}
+	return fmt.Sprintf("%s: %s", s, c.Message)
+}
+
+// Report represents the list of changes
+// between two OpenAPI documents.
+type Report struct {
+	Changes []*Change `json:"changes"`
+}
+
+// Breaking returns the breaking changes of the report.
+func (r *Report) Breaking() []*Change {
+	var changes []*Change
+	for _, c := range r.Changes {
+		if c.Breaking {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// HasBreaking returns whether the report
+// contains at least one breaking change.
+func (r *Report) HasBreaking() bool {
+	return len(r.Breaking()) != 0
+}
+
+// Markdown returns the report formatted in Markdown,
+// with the breaking and non-breaking changes listed
+// in separate tables.
+func (r *Report) Markdown() string {
+	b := &markdown.Builder{}
+
+	if len(r.Changes) == 0 {
+		return b.P("No changes.").String()
+	}
+	var breaking, others [][]string
+	for _, c := range r.Changes {
+		row := []string{
+			b.InlineCode(c.Method + " " + c.Path),
+			b.InlineCode(c.Location),
+			string(c.Kind),
+			c.Message,
+		}
+		if c.Location == "" {
+			row[1] = ""
+		}
+		if c.Breaking {
+			breaking = append(breaking, row)
+		} else {
+			others = append(others, row)
+		}
+	}
+	header := []string{"Operation", "Location", "Kind", "Description"}
+
+	if len(breaking) != 0 {
+		b.H2("Breaking changes")
+		b.Table(append([][]string{header}, breaking...), nil)
+	}
+	if len(others) != 0 {
+		b.H2("Non-breaking changes")
+		b.Table(append([][]string{header}, others...), nil)
+	}
+	return b.String()
+}
+
+// direction indicates whether a schema describes
+// a value sent by the clients or by the server.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+// differ holds the state of the comparison
+// of two OpenAPI documents.
+type differ struct {
+	base, revision *openapi.OpenAPI
+	changes        []*Change
+
+	// Current operation.
+	method, path string
+}
+
+// Compare compares the documents base and revision,
+// and returns the report of the changes made to the
+// operations of base by revision. A nil document
+// is compared as an empty one.
+func Compare(base, revision *openapi.OpenAPI) *Report {
+	if base == nil {
+		base = &openapi.OpenAPI{}
+	}
+	if revision == nil {
+		revision = &openapi.OpenAPI{}
+	}
+	d := &differ{
+		base:     base,
+		revision: revision,
+	}
+	d.compare()
+
+	return &Report{Changes: d.changes}
+}
+
+func (d *differ) add(kind Kind, loc string, breaking bool, format string, a ...interface{}) {
+	d.changes = append(d.changes, &Change{
+		Kind:     kind,
+		Method:   d.method,
+		Path:     d.path,
+		Location: loc,
+		Message:  fmt.Sprintf(format, a...),
+		Breaking: breaking,
+	})
+}
+
+func (d *differ) compare() {
+	paths := make(map[string]bool)
+	for p := range d.base.Paths {
+		paths[p] = true
+	}
+	for p := range d.revision.Paths {
+		paths[p] = true
+	}
+	for _, path := range openapi.SortedKeys(paths) {
+		bi, ri := d.base.Paths[path], d.revision.Paths[path]
+
+		for _, m := range methods {
+			var bop, rop *openapi.Operation
+			if bi != nil {
+				bop = bi.Operation(m)
+			}
+			if ri != nil {
+				rop = ri.Operation(m)
+			}
+			d.method, d.path = m, path
+
+			switch {
+			case bop == nil && rop == nil:
+				continue
+			case bop == nil:
+				d.add(OperationAdded, "", false, "operation added")
+			case rop == nil:
+				d.add(OperationRemoved, "", true, "operation removed")
+			default:
+				d.compareOperation(bi, bop, ri, rop)
+			}
+		}
+	}
+}
+
+func (d *differ) compareOperation(bi *openapi.PathItem, bop *openapi.Operation, ri *openapi.PathItem, rop *openapi.Operation) {
+	d.compareParameters(
+		parameters(d.base, bi, bop),
+		parameters(d.revision, ri, rop),
+	)
+	d.compareRequestBody(bop.RequestBody, rop.RequestBody)
+	d.compareResponses(bop.Responses, rop.Responses)
+	d.compareSecurity(bop.Security, rop.Security)
+}
+
+// parameters returns the parameters of an operation
+// indexed by location and name, including those of
+// its path item that the operation does not override.
+// The names of the header parameters are canonicalized,
+// since they are case-insensitive.
+func parameters(api *openapi.OpenAPI, pi *openapi.PathItem, op *openapi.Operation) map[string]*openapi.Parameter {
+	m := make(map[string]*openapi.Parameter)
+	for _, params := range [][]*openapi.ParameterOrRef{pi.Parameters, op.Parameters} {
+		for _, por := range params {
+			if p := api.ResolveParameter(por); p != nil {
+				name := p.Name
+				if p.In == "header" {
+					name = http.CanonicalHeaderKey(name)
+				}
+				m[p.In+"."+name] = p
+			}
+		}
+	}
+	return m
+}
+
+func (d *differ) compareParameters(bm, rm map[string]*openapi.Parameter) {
+	keys := make(map[string]bool)
+	for k := range bm {
+		keys[k] = true
+	}
+	for k := range rm {
+		keys[k] = true
+	}
+	for _, k := range openapi.SortedKeys(keys) {
+		bp, rp := bm[k], rm[k]
+		loc := "request." + k
+
+		switch {
+		case bp == nil:
+			if rp.Required {
+				d.add(ParameterAdded, loc, true, "required parameter added")
+			} else {
+				d.add(ParameterAdded, loc, false, "optional parameter added")
+			}
+		case rp == nil:
+			d.add(ParameterRemoved, loc, true, "parameter removed")
+		default:
+			if !bp.Required && rp.Required {
+				d.add(ParameterRequired, loc, true, "parameter became required")
+			} else if bp.Required && !rp.Required {
+				d.add(ParameterOptional, loc, false, "parameter became optional")
+			}
+			d.compareSchema(bp.Schema, rp.Schema, loc, request, make(map[string]bool))
+		}
+	}
+}
+
+func (d *differ) compareRequestBody(brb, rrb *openapi.RequestBody) {
+	const loc = "request.body"
+
+	switch {
+	case brb == nil && rrb == nil:
+		return
+	case brb == nil:
+		if rrb.Required {
+			d.add(RequestBodyAdded, loc, true, "required request body added")
+		} else {
+			d.add(RequestBodyAdded, loc, false, "optional request body added")
+		}
+		return
+	case rrb == nil:
+		d.add(RequestBodyRemoved, loc, false, "request body removed")
+		return
+	}
+	if !brb.Required && rrb.Required {
+		d.add(RequestBodyRequired, loc, true, "request body became required")
+	} else if brb.Required && !rrb.Required {
+		d.add(RequestBodyOptional, loc, false, "request body became optional")
+	}
+	mts := make(map[string]bool)
+	for mt := range brb.Content {
+		mts[mt] = true
+	}
+	for mt := range rrb.Content {
+		mts[mt] = true
+	}
+	for _, mt := range openapi.SortedKeys(mts) {
+		bmt, rmt := brb.Content[mt], rrb.Content[mt]
+		switch {
+		case bmt == nil:
+			d.add(MediaTypeAdded, loc, false, "media type %s added", mt)
+		case rmt == nil:
+			d.add(MediaTypeRemoved, loc, true, "media type %s removed", mt)
+		default:
+			d.compareSchema(bmt.Schema, rmt.Schema, loc, request, make(map[string]bool))
+		}
+	}
+}
+
+func (d *differ) compareResponses(bresps, rresps openapi.Responses) {
+	codes := make(map[string]bool)
+	for c := range bresps {
+		codes[c] = true
+	}
+	for c := range rresps {
+		codes[c] = true
+	}
+	for _, code := range openapi.SortedKeys(codes) {
+		br := d.base.ResolveResponse(bresps[code])
+		rr := d.revision.ResolveResponse(rresps[code])
+		loc := "response." + code
+
+		switch {
+		case br == nil && rr == nil:
+			continue
+		case br == nil:
+			d.add(ResponseAdded, loc, false, "response added")
+			continue
+		case rr == nil:
+			d.add(ResponseRemoved, loc, true, "response removed")
+			continue
+		}
+		d.compareHeaders(br.Headers, rr.Headers, loc)
+
+		mts := make(map[string]bool)
+		for mt := range br.Content {
+			mts[mt] = true
+		}
+		for mt := range rr.Content {
+			mts[mt] = true
+		}
+		for _, mt := range openapi.SortedKeys(mts) {
+			bmt, rmt := br.Content[mt], rr.Content[mt]
+			switch {
+			case bmt == nil:
+				d.add(MediaTypeAdded, loc, false, "media type %s added", mt)
+			case rmt == nil:
+				d.add(MediaTypeRemoved, loc, true, "media type %s removed", mt)
+			case bmt.MediaType != nil && rmt.MediaType != nil:
+				d.compareSchema(bmt.Schema, rmt.Schema, loc+".body", response, make(map[string]bool))
+			}
+		}
+	}
+}
+
+// compareHeaders compares the headers of a response.
+// The clients may rely on a header of a response, thus
+// removing it, or making it optional, breaks them.
+func (d *differ) compareHeaders(bhs, rhs map[string]*openapi.HeaderOrRef, loc string) {
+	bm := headers(d.base, bhs)
+	rm := headers(d.revision, rhs)
+
+	names := make(map[string]bool)
+	for n := range bm {
+		names[n] = true
+	}
+	for n := range rm {
+		names[n] = true
+	}
+	for _, name := range openapi.SortedKeys(names) {
+		bh, rh := bm[name], rm[name]
+		hloc := loc + ".header." + name
+
+		switch {
+		case bh == nil:
+			d.add(HeaderAdded, hloc, false, "header added")
+		case rh == nil:
+			d.add(HeaderRemoved, hloc, true, "header removed")
+		default:
+			if !bh.Required && rh.Required {
+				d.add(HeaderRequired, hloc, false, "header became required")
+			} else if bh.Required && !rh.Required {
+				d.add(HeaderOptional, hloc, true, "header became optional")
+			}
+			d.compareSchema(bh.Schema, rh.Schema, hloc, response, make(map[string]bool))
+		}
+	}
+}
+
+// headers returns the resolved headers indexed
+// by their canonical name.
+func headers(api *openapi.OpenAPI, hs map[string]*openapi.HeaderOrRef) map[string]*openapi.Header {
+	m := make(map[string]*openapi.Header, len(hs))
+	for name, hor := range hs {
+		if h := api.ResolveHeader(hor); h != nil {
+			m[http.CanonicalHeaderKey(name)] = h
+		}
+	}
+	return m
+}
+
+// compareSecurity compares the effective security
+// requirements of the operations. The requirements
+// are alternatives; clients that use an alternative
+// removed by the revision are broken, as well as all
+// clients if the revision secures an unsecured
+// operation.
+func (d *differ) compareSecurity(bsec, rsec []*openapi.SecurityRequirement) {
+	if bsec == nil {
+		bsec = d.base.Security
+	}
+	if rsec == nil {
+		rsec = d.revision.Security
+	}
+	bm, bopt := securityKeys(bsec)
+	rm, ropt := securityKeys(rsec)
+
+	for _, k := range openapi.SortedKeys(bm) {
+		if !rm[k] {
+			d.add(SecurityRemoved, "security", !ropt, "security requirement %s removed", k)
+		}
+	}
+	var added int
+	for _, k := range openapi.SortedKeys(rm) {
+		if !bm[k] {
+			d.add(SecurityAdded, "security", bopt && !ropt, "security requirement %s added", k)
+			added++
+		}
+	}
+	switch {
+	case bopt && !ropt && added == 0:
+		d.add(SecurityAdded, "security", true, "security became mandatory")
+	case !bopt && ropt && len(rm) != 0:
+		d.add(SecurityRemoved, "security", false, "security became optional")
+	}
+}
+
+// securityKeys returns the string representation of
+// each requirement, and whether the security is
+// optional, that is, if an empty requirement exists
+// or if there is no requirement at all.
+func securityKeys(sec []*openapi.SecurityRequirement) (map[string]bool, bool) {
+	keys := make(map[string]bool, len(sec))
+	optional := len(sec) == 0
+
+	for _, req := range sec {
+		if req == nil || len(*req) == 0 {
+			optional = true
+			continue
+		}
+		var parts []string
+		for name, scopes := range *req {
+			s := append([]string(nil), scopes...)
+			sort.Strings(s)
+			if len(s) != 0 {
+				name += fmt.Sprintf("[%s]", strings.Join(s, ","))
+			}
+			parts = append(parts, name)
+		}
+		sort.Strings(parts)
+		keys[strings.Join(parts, "+")] = true
+	}
+	return keys, optional
+}
+
+// compareSchema compares the schemas bsor and rsor
+// of a value sent in the given direction. The refs
+// map holds the pairs of references being compared,
+// to stop on recursive schemas.
+func (d *differ) compareSchema(bsor, rsor *openapi.SchemaOrRef, loc string, dir direction, refs map[string]bool) {
+	if bn, rn := openapi.SchemaName(bsor), openapi.SchemaName(rsor); bn != "" || rn != "" {
+		key := bn + "|" + rn
+		if refs[key] {
+			return
+		}
+		refs[key] = true
+		defer delete(refs, key)
+	}
+	bs := d.base.ResolveSchema(bsor)
+	rs := d.revision.ResolveSchema(rsor)
+	if bs == nil || rs == nil {
+		return
+	}
+	if bs.Type != rs.Type || bs.Format != rs.Format {
+		// An integer is a valid number, thus the clients are
+		// not broken by a request value that became a number,
+		// or by a response value that became an integer.
+		widened := (dir == request && bs.Type == "integer" && rs.Type == "number") ||
+			(dir == response && bs.Type == "number" && rs.Type == "integer")
+
+		d.add(TypeChanged, loc, !widened, "type changed from %s to %s", typeString(bs), typeString(rs))
+		if !widened {
+			return
+		}
+	}
+	if bs.Nullable != rs.Nullable {
+		// A request value that can no longer be null breaks
+		// the clients, as does a nullable response value.
+		breaking := (dir == request && !rs.Nullable) || (dir == response && rs.Nullable)
+		if rs.Nullable {
+			d.add(NullableChanged, loc, breaking, "value became nullable")
+		} else {
+			d.add(NullableChanged, loc, breaking, "value became non-nullable")
+		}
+	}
+	d.compareEnum(bs.Enum, rs.Enum, loc, dir)
+
+	if bs.Items != nil || rs.Items != nil {
+		d.compareSchema(bs.Items, rs.Items, loc+"[]", dir, refs)
+	}
+	if bs.AdditionalProperties != nil && rs.AdditionalProperties != nil {
+		d.compareSchema(bs.AdditionalProperties, rs.AdditionalProperties, loc+".*", dir, refs)
+	}
+	d.compareProperties(bs, rs, loc, dir, refs)
+	d.compareSubschemas("allOf", bs.AllOf, rs.AllOf, loc, dir, refs)
+	d.compareSubschemas("oneOf", bs.OneOf, rs.OneOf, loc, dir, refs)
+	d.compareSubschemas("anyOf", bs.AnyOf, rs.AnyOf, loc, dir, refs)
+}
+
+// compareSubschemas compares the subschemas of the
+// given composition keyword. The referenced subschemas
+// are paired by name, and the inlined ones by position.
+// Adding a subschema to allOf narrows the valid values,
+// whereas adding one to oneOf or anyOf widens them.
+func (d *differ) compareSubschemas(keyword string, bsubs, rsubs []*openapi.SchemaOrRef, loc string, dir direction, refs map[string]bool) {
+	if len(bsubs) == 0 && len(rsubs) == 0 {
+		return
+	}
+	narrowing := keyword == "allOf"
+
+	bm := subschemas(bsubs)
+	rm := subschemas(rsubs)
+
+	keys := make(map[string]bool)
+	for k := range bm {
+		keys[k] = true
+	}
+	for k := range rm {
+		keys[k] = true
+	}
+	for _, k := range openapi.SortedKeys(keys) {
+		bsub, rsub := bm[k], rm[k]
+		sloc := fmt.Sprintf("%s.%s[%s]", loc, keyword, k)
+
+		switch {
+		case bsub == nil:
+			d.add(SubschemaAdded, sloc, (dir == request) == narrowing, "%s subschema added", keyword)
+		case rsub == nil:
+			d.add(SubschemaRemoved, sloc, (dir == response) == narrowing, "%s subschema removed", keyword)
+		default:
+			d.compareSchema(bsub, rsub, sloc, dir, refs)
+		}
+	}
+}
+
+// subschemas indexes the subschemas by the name of
+// the schema they reference, or by their position.
+func subschemas(list []*openapi.SchemaOrRef) map[string]*openapi.SchemaOrRef {
+	m := make(map[string]*openapi.SchemaOrRef, len(list))
+	for i, sor := range list {
+		if sor == nil {
+			continue
+		}
+		k := openapi.SchemaName(sor)
+		if k == "" {
+			k = strconv.Itoa(i)
+		}
+		m[k] = sor
+	}
+	return m
+}
+
+func (d *differ) compareProperties(bs, rs *openapi.Schema, loc string, dir direction, refs map[string]bool) {
+	breq := stringSet(bs.Required)
+	rreq := stringSet(rs.Required)
+
+	names := make(map[string]bool)
+	for n := range bs.Properties {
+		names[n] = true
+	}
+	for n := range rs.Properties {
+		names[n] = true
+	}
+	for _, name := range openapi.SortedKeys(names) {
+		bp, rp := bs.Properties[name], rs.Properties[name]
+		ploc := loc + "." + name
+
+		switch {
+		case bp == nil:
+			// A new required property must be
+			// sent by the clients.
+			breaking := dir == request && rreq[name]
+			d.add(PropertyAdded, ploc, breaking, "property added")
+		case rp == nil:
+			// The clients may rely on a
+			// property of a response.
+			d.add(PropertyRemoved, ploc, dir == response, "property removed")
+		default:
+			if !breq[name] && rreq[name] {
+				d.add(PropertyRequired, ploc, dir == request, "property became required")
+			} else if breq[name] && !rreq[name] {
+				d.add(PropertyOptional, ploc, dir == response, "property became optional")
+			}
+			d.compareSchema(bp, rp, ploc, dir, refs)
+		}
+	}
+}
+
+// compareEnum compares the enumerations of values.
+// Removing a value that clients can send breaks them,
+// as does adding a value that they can receive.
+func (d *differ) compareEnum(benum, renum []interface{}, loc string, dir direction) {
+	if len(benum) == 0 && len(renum) == 0 {
+		return
+	}
+	bset := valueSet(benum)
+	rset := valueSet(renum)
+
+	var removed, added []string
+	for _, v := range openapi.SortedKeys(bset) {
+		if !rset[v] {
+			removed = append(removed, v)
+		}
+	}
+	for _, v := range openapi.SortedKeys(rset) {
+		if !bset[v] {
+			added = append(added, v)
+		}
+	}
+	// An empty enum allows any value.
+	switch {
+	case len(benum) == 0:
+		d.add(EnumNarrowed, loc, dir == request, "enum added: %s", strings.Join(added, ", "))
+		return
+	case len(renum) == 0:
+		d.add(EnumWidened, loc, dir == response, "enum removed")
+		return
+	}
+	if len(removed) != 0 {
+		d.add(EnumNarrowed, loc, dir == request, "enum values removed: %s", strings.Join(removed, ", "))
+	}
+	if len(added) != 0 {
+		d.add(EnumWidened, loc, dir == response, "enum values added: %s", strings.Join(added, ", "))
+	}
+}
+
+func typeString(s *openapi.Schema) string {
+	t := s.Type
+	if t == "" {
+		t = "any"
+	}
+	if s.Format != "" {
+		t += fmt.Sprintf(" (%s)", s.Format)
+	}
+	return t
+}
+
+func stringSet(list []string) map[string]bool {
+	m := make(map[string]bool, len(list))
+	for _, s := range list {
+		m[s] = true
+	}
+	return m
+}
+
+func valueSet(list []interface{}) map[string]bool {
+	m := make(map[string]bool, len(list))
+	for _, v := range list {
+		m[fmt.Sprintf("%v", v)] = true
+	}
+	return m
+}
@@ -0,0 +1,359 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/loopfz/gadgeto/tonic"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+type (
+	inV1 struct {
+		Limit int    `query:"limit"`
+		Sort  string `query:"sort" enum:"asc,desc"`
+		Name  string `json:"name"`
+	}
+	inV2 struct {
+		Limit  int    `query:"limit" validate:"required"`
+		Sort   string `query:"sort" enum:"asc"`
+		Filter string `query:"filter"`
+		Name   string `json:"name"`
+		Email  string `json:"email" validate:"required"`
+	}
+	outV1 struct {
+		ID     string `json:"id"`
+		Status string `json:"status" enum:"active,disabled"`
+		Count  int    `json:"count"`
+	}
+	outV2 struct {
+		Status string  `json:"status" enum:"active,disabled,pending"`
+		Count  string  `json:"count"`
+		Score  float64 `json:"score"`
+	}
+)
+
+func newGenerator(t *testing.T) *openapi.Generator {
+	g, err := openapi.NewGenerator(&openapi.SpecGenConfig{
+		ValidatorTag:      tonic.ValidationTag,
+		PathLocationTag:   tonic.PathTag,
+		QueryLocationTag:  tonic.QueryTag,
+		HeaderLocationTag: tonic.HeaderTag,
+		EnumTag:           tonic.EnumTag,
+		DefaultTag:        tonic.DefaultTag,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.UseFullSchemaNames(false)
+
+	return g
+}
+
+func addOperation(t *testing.T, g *openapi.Generator, path, method string, in, out interface{}, security []*openapi.SecurityRequirement) {
+	_, err := g.AddOperation(path, method, "", reflect.TypeOf(in), reflect.TypeOf(out), &openapi.OperationInfo{
+		ID:         method + path,
+		StatusCode: 200,
+		Security:   security,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCompare tests that the changes between two
+// documents are detected and properly classified.
+func TestCompare(t *testing.T) {
+	base := newGenerator(t)
+	addOperation(t, base, "/items", "POST", &inV1{}, &outV1{}, nil)
+	addOperation(t, base, "/legacy", "GET", nil, &outV1{}, nil)
+
+	rev := newGenerator(t)
+	addOperation(t, rev, "/items", "POST", &inV2{}, &outV2{}, []*openapi.SecurityRequirement{
+		{"apiKey": []string{}},
+	})
+	addOperation(t, rev, "/new", "GET", nil, &outV2{}, nil)
+
+	report := Compare(base.API(), rev.API())
+
+	type change struct {
+		kind     Kind
+		loc      string
+		breaking bool
+	}
+	var changes []change
+	for _, c := range report.Changes {
+		changes = append(changes, change{c.Kind, c.Location, c.Breaking})
+	}
+	assert.ElementsMatch(t, []change{
+		{ParameterAdded, "request.query.filter", false},
+		{ParameterRequired, "request.query.limit", true},
+		{EnumNarrowed, "request.query.sort", true},
+		{PropertyAdded, "request.body.email", true},
+		{TypeChanged, "response.200.body.count", true},
+		{PropertyRemoved, "response.200.body.id", true},
+		{PropertyAdded, "response.200.body.score", false},
+		{EnumWidened, "response.200.body.status", true},
+		{SecurityAdded, "security", true},
+		{OperationRemoved, "", true},
+		{OperationAdded, "", false},
+	}, changes)
+
+	assert.True(t, report.HasBreaking())
+	assert.Len(t, report.Breaking(), 8)
+
+	md := report.Markdown()
+	assert.True(t, strings.HasPrefix(md, "## Breaking changes"))
+	assert.Contains(t, md, "## Non-breaking changes")
+	assert.Contains(t, md, "| `POST /items` | `request.query.limit`      | parameter-became-required |")
+
+	// Comparing a document with
+	// itself yields no changes.
+	report = Compare(base.API(), base.API())
+	assert.Empty(t, report.Changes)
+	assert.False(t, report.HasBreaking())
+	assert.Equal(t, "No changes.", report.Markdown())
+}
+
+// TestComparePathParameters tests that the parameters
+// of the path items are compared, and that those an
+// operation overrides are not.
+func TestComparePathParameters(t *testing.T) {
+	param := func(name string, required bool) *openapi.ParameterOrRef {
+		return &openapi.ParameterOrRef{Parameter: &openapi.Parameter{
+			Name:     name,
+			In:       "query",
+			Required: required,
+			Schema:   &openapi.SchemaOrRef{Schema: &openapi.Schema{Type: "string"}},
+		}}
+	}
+	doc := func(pathParams, opParams []*openapi.ParameterOrRef) *openapi.OpenAPI {
+		return &openapi.OpenAPI{Paths: openapi.Paths{
+			"/items": &openapi.PathItem{
+				Parameters: pathParams,
+				GET:        &openapi.Operation{Parameters: opParams},
+			},
+		}}
+	}
+	base := doc([]*openapi.ParameterOrRef{param("page", false), param("sort", false)}, nil)
+	rev := doc(
+		[]*openapi.ParameterOrRef{param("page", true), param("sort", true), param("lang", true)},
+		[]*openapi.ParameterOrRef{param("sort", false)},
+	)
+	report := Compare(base, rev)
+
+	var locs []string
+	for _, c := range report.Changes {
+		locs = append(locs, string(c.Kind)+" "+c.Location)
+	}
+	assert.ElementsMatch(t, []string{
+		"parameter-added request.query.lang",
+		"parameter-became-required request.query.page",
+	}, locs)
+}
+
+// TestCompareSubschemas tests that the subschemas of
+// the compositions are compared and that the added and
+// removed ones are classified by keyword and direction.
+func TestCompareSubschemas(t *testing.T) {
+	ref := func(name string) *openapi.SchemaOrRef {
+		return &openapi.SchemaOrRef{Reference: &openapi.Reference{Ref: "#/components/schemas/" + name}}
+	}
+	doc := func(req, resp *openapi.Schema, cat *openapi.Schema) *openapi.OpenAPI {
+		return &openapi.OpenAPI{
+			Paths: openapi.Paths{
+				"/pets": &openapi.PathItem{
+					POST: &openapi.Operation{
+						RequestBody: &openapi.RequestBody{
+							Content: map[string]*openapi.MediaType{
+								"application/json": {Schema: &openapi.SchemaOrRef{Schema: req}},
+							},
+						},
+						Responses: openapi.Responses{
+							"200": {Response: &openapi.Response{
+								Content: map[string]*openapi.MediaTypeOrRef{
+									"application/json": {MediaType: &openapi.MediaType{
+										Schema: &openapi.SchemaOrRef{Schema: resp},
+									}},
+								},
+							}},
+						},
+					},
+				},
+			},
+			Components: &openapi.Components{
+				Schemas: map[string]*openapi.SchemaOrRef{
+					"Cat":  {Schema: cat},
+					"Dog":  {Schema: &openapi.Schema{Type: "object"}},
+					"Bird": {Schema: &openapi.Schema{Type: "object"}},
+				},
+			},
+		}
+	}
+	base := doc(
+		&openapi.Schema{AllOf: []*openapi.SchemaOrRef{ref("Cat")}},
+		&openapi.Schema{OneOf: []*openapi.SchemaOrRef{ref("Cat"), ref("Dog")}},
+		&openapi.Schema{Type: "object"},
+	)
+	rev := doc(
+		&openapi.Schema{AllOf: []*openapi.SchemaOrRef{ref("Cat"), {Schema: &openapi.Schema{Type: "object"}}}},
+		&openapi.Schema{OneOf: []*openapi.SchemaOrRef{ref("Bird"), ref("Cat")}},
+		&openapi.Schema{Type: "string"},
+	)
+	report := Compare(base, rev)
+
+	type change struct {
+		kind     Kind
+		loc      string
+		breaking bool
+	}
+	var changes []change
+	for _, c := range report.Changes {
+		changes = append(changes, change{c.Kind, c.Location, c.Breaking})
+	}
+	assert.ElementsMatch(t, []change{
+		{SubschemaAdded, "request.body.allOf[1]", true},
+		{TypeChanged, "request.body.allOf[Cat]", true},
+		{SubschemaAdded, "response.200.body.oneOf[Bird]", true},
+		{TypeChanged, "response.200.body.oneOf[Cat]", true},
+		{SubschemaRemoved, "response.200.body.oneOf[Dog]", false},
+	}, changes)
+}
+
+// TestCompareNil tests that the nil documents and
+// the documents without components can be compared.
+func TestCompareNil(t *testing.T) {
+	doc := &openapi.OpenAPI{
+		Paths: openapi.Paths{
+			"/items": &openapi.PathItem{
+				GET: &openapi.Operation{
+					Parameters: []*openapi.ParameterOrRef{
+						{Reference: &openapi.Reference{Ref: "#/components/parameters/Page"}},
+					},
+					Responses: openapi.Responses{
+						"200": {Reference: &openapi.Reference{Ref: "#/components/responses/Items"}},
+					},
+				},
+			},
+		},
+	}
+	assert.Empty(t, Compare(nil, nil).Changes)
+	assert.Empty(t, Compare(doc, doc).Changes)
+
+	report := Compare(nil, doc)
+	if assert.Len(t, report.Changes, 1) {
+		assert.Equal(t, OperationAdded, report.Changes[0].Kind)
+	}
+	report = Compare(doc, nil)
+	if assert.Len(t, report.Changes, 1) {
+		assert.Equal(t, OperationRemoved, report.Changes[0].Kind)
+	}
+}
+
+// TestCompareHeaders tests that the headers of the
+// responses and the header parameters are compared,
+// regardless of the case of their names.
+func TestCompareHeaders(t *testing.T) {
+	header := func(typ string, required bool) *openapi.HeaderOrRef {
+		return &openapi.HeaderOrRef{Header: &openapi.Header{
+			Required: required,
+			Schema:   &openapi.SchemaOrRef{Schema: &openapi.Schema{Type: typ}},
+		}}
+	}
+	doc := func(param string, headers map[string]*openapi.HeaderOrRef) *openapi.OpenAPI {
+		return &openapi.OpenAPI{
+			Paths: openapi.Paths{
+				"/items": &openapi.PathItem{
+					GET: &openapi.Operation{
+						Parameters: []*openapi.ParameterOrRef{{Parameter: &openapi.Parameter{
+							Name:   param,
+							In:     "header",
+							Schema: &openapi.SchemaOrRef{Schema: &openapi.Schema{Type: "string"}},
+						}}},
+						Responses: openapi.Responses{
+							"200": {Response: &openapi.Response{Description: "OK", Headers: headers}},
+						},
+					},
+				},
+			},
+			Components: &openapi.Components{
+				Headers: map[string]*openapi.HeaderOrRef{
+					"Limit": header("integer", true),
+				},
+			},
+		}
+	}
+	base := doc("X-Request-ID", map[string]*openapi.HeaderOrRef{
+		"X-Rate-Limit": {Reference: &openapi.Reference{Ref: "#/components/headers/Limit"}},
+		"X-Total":      header("integer", true),
+		"X-Page":       header("integer", false),
+		"ETag":         header("string", false),
+	})
+	rev := doc("x-request-id", map[string]*openapi.HeaderOrRef{
+		"x-rate-limit": header("integer", true),
+		"X-Total":      header("integer", false),
+		"X-Page":       header("string", true),
+		"X-Cursor":     header("string", false),
+	})
+	report := Compare(base, rev)
+
+	type change struct {
+		kind     Kind
+		loc      string
+		breaking bool
+	}
+	var changes []change
+	for _, c := range report.Changes {
+		changes = append(changes, change{c.Kind, c.Location, c.Breaking})
+	}
+	assert.ElementsMatch(t, []change{
+		{HeaderRemoved, "response.200.header.Etag", true},
+		{HeaderAdded, "response.200.header.X-Cursor", false},
+		{HeaderRequired, "response.200.header.X-Page", false},
+		{TypeChanged, "response.200.header.X-Page", true},
+		{HeaderOptional, "response.200.header.X-Total", true},
+	}, changes)
+}
+
+// TestCompareNumberTypes tests that an integer that
+// becomes a number is a breaking change only for the
+// values of the responses.
+func TestCompareNumberTypes(t *testing.T) {
+	type (
+		in1 struct {
+			N int `query:"n"`
+		}
+		in2 struct {
+			N float64 `query:"n"`
+		}
+		out1 struct {
+			A int     `json:"a"`
+			B float64 `json:"b"`
+		}
+		out2 struct {
+			A float64 `json:"a"`
+			B int     `json:"b"`
+		}
+	)
+	base := newGenerator(t)
+	addOperation(t, base, "/items", "GET", &in1{}, &out1{}, nil)
+
+	rev := newGenerator(t)
+	addOperation(t, rev, "/items", "GET", &in2{}, &out2{}, nil)
+
+	report := Compare(base.API(), rev.API())
+
+	breaking := make(map[string]bool)
+	for _, c := range report.Changes {
+		assert.Equal(t, TypeChanged, c.Kind)
+		breaking[c.Location] = c.Breaking
+	}
+	assert.Equal(t, map[string]bool{
+		"request.query.n":     false,
+		"response.200.body.a": true,
+		"response.200.body.b": false,
+	}, breaking)
+}
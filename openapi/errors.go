@@ -32,3 +32,18 @@ type TypeError struct {
 func (te *TypeError) Error() string {
 	return fmt.Sprintf("%s: type=%s, kind=%s", te.Message, te.Type, te.Type.Kind())
 }
+
+// NameCollisionError is the error returned when the
+// component schema name of a type is already used by
+// another type. For the request bodies, the type is
+// the input type of the operation.
+type NameCollisionError struct {
+	Name  string
+	Type  reflect.Type
+	Owner reflect.Type
+}
+
+// Error implements the builtin error interface for NameCollisionError.
+func (nce *NameCollisionError) Error() string {
+	return fmt.Sprintf("schema name %s is already used by another type: type=%s, owner=%s", nce.Name, nce.Type, nce.Owner)
+}
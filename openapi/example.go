@@ -0,0 +1,254 @@
+package openapi
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// maxExampleDepth is the maximum depth of the
+// values generated by GenerateExample.
+const maxExampleDepth = 8
+
+// Sample values of the string formats.
+var formatExamples = map[string]string{
+	"date-time": "1970-01-01T00:00:00Z",
+	"date":      "1970-01-01",
+	"time":      "00:00:00",
+	"duration":  "PT1S",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "127.0.0.1",
+	"ipv6":      "::1",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+	"byte":      "c3RyaW5n",
+}
+
+// GenerateExample returns an example value for the
+// schema sor, in a form that can be marshaled to
+// JSON. The example, default, const and enum values
+// of the schemas are used when present, otherwise
+// a value that satisfies the type and the bounds of
+// the schema is generated. The references to other
+// schemas are resolved using the components of the
+// document api.
+func GenerateExample(api *OpenAPI, sor *SchemaOrRef) interface{} {
+	eg := &exampleGenerator{
+		api:  api,
+		refs: make(map[string]bool),
+	}
+	return eg.generate(sor, 0)
+}
+
+type exampleGenerator struct {
+	api  *OpenAPI
+	refs map[string]bool // references being expanded
+}
+
+func (eg *exampleGenerator) generate(sor *SchemaOrRef, depth int) interface{} {
+	if depth > maxExampleDepth {
+		return nil
+	}
+	// Stop on recursive references.
+	if name := SchemaName(sor); name != "" {
+		if eg.refs[name] {
+			return nil
+		}
+		eg.refs[name] = true
+		defer delete(eg.refs, name)
+	}
+	schema := eg.api.ResolveSchema(sor)
+	if schema == nil {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case schema.Const != nil:
+		return schema.Const
+	}
+	for _, v := range schema.Enum {
+		if v != nil {
+			return v
+		}
+	}
+	if len(schema.AllOf) != 0 {
+		obj := make(map[string]interface{})
+		for _, s := range schema.AllOf {
+			if m, ok := eg.generate(s, depth+1).(map[string]interface{}); ok {
+				for k, v := range m {
+					obj[k] = v
+				}
+			}
+		}
+		return obj
+	}
+	if len(schema.OneOf) != 0 {
+		return eg.polymorphic(schema, schema.OneOf, depth)
+	}
+	if len(schema.AnyOf) != 0 {
+		return eg.polymorphic(schema, schema.AnyOf, depth)
+	}
+	switch schema.Type {
+	case "string":
+		// A pattern cannot be satisfied reliably, the
+		// schema has no example if the value doesn't
+		// match it.
+		s := stringExample(schema)
+		if schema.Pattern != "" {
+			re, err := compilePattern(schema.Pattern)
+			if err != nil || !re.MatchString(s) {
+				return nil
+			}
+		}
+		return s
+	case "integer":
+		return integerExample(schema)
+	case "number":
+		return numberExample(schema)
+	case "boolean":
+		return true
+	case "array":
+		item := eg.generate(schema.Items, depth+1)
+		n := 1
+		if schema.MinItems > 1 && !schema.UniqueItems {
+			n = schema.MinItems
+		}
+		arr := make([]interface{}, n)
+		for i := range arr {
+			arr[i] = item
+		}
+		return arr
+	case "object", "":
+		return eg.object(schema, depth)
+	}
+	return nil
+}
+
+func (eg *exampleGenerator) object(schema *Schema, depth int) interface{} {
+	if schema.Type == "" && len(schema.Properties) == 0 && schema.AdditionalProperties == nil {
+		return nil
+	}
+	obj := make(map[string]interface{}, len(schema.Properties))
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		obj[name] = eg.generate(schema.Properties[name], depth+1)
+	}
+	if len(obj) == 0 && schema.AdditionalProperties != nil {
+		obj["key"] = eg.generate(schema.AdditionalProperties, depth+1)
+	}
+	return obj
+}
+
+// polymorphic returns an example of the first schema
+// of the list, with the discriminator property set.
+func (eg *exampleGenerator) polymorphic(schema *Schema, schemas []*SchemaOrRef, depth int) interface{} {
+	v := eg.generate(schemas[0], depth+1)
+
+	if d := schema.Discriminator; d != nil {
+		if m, ok := v.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(d.Mapping))
+			for k, ref := range d.Mapping {
+				if schemas[0].Reference != nil && ref == schemas[0].Ref {
+					keys = append(keys, k)
+				}
+			}
+			if len(keys) != 0 {
+				sort.Strings(keys)
+				m[d.PropertyName] = keys[0]
+			}
+		}
+	}
+	return v
+}
+
+func stringExample(schema *Schema) string {
+	s, ok := formatExamples[schema.Format]
+	if !ok {
+		s = "string"
+	}
+	if schema.MinLength > len(s) {
+		s += strings.Repeat("x", schema.MinLength-len(s))
+	}
+	if schema.MaxLength != 0 && len(s) > schema.MaxLength {
+		s = s[:schema.MaxLength]
+	}
+	return s
+}
+
+// integerExample returns an integer within the bounds
+// of the schema, which are rounded toward the inside
+// of the range.
+func integerExample(schema *Schema) int64 {
+	var (
+		f float64
+		m = schema.MultipleOf
+	)
+	if min := schema.Minimum; min != nil {
+		lo := math.Ceil(*min)
+		if schema.ExclusiveMinimum && lo == *min {
+			lo++
+		}
+		if f < lo {
+			f = lo
+		}
+	}
+	if m != nil && *m > 0 {
+		f = math.Ceil(f / *m) * *m
+	}
+	if max := schema.Maximum; max != nil {
+		hi := math.Floor(*max)
+		if schema.ExclusiveMaximum && hi == *max {
+			hi--
+		}
+		if f > hi {
+			f = hi
+			if m != nil && *m > 0 {
+				f = math.Floor(f / *m) * *m
+			}
+		}
+	}
+	return int64(f)
+}
+
+func numberExample(schema *Schema) float64 {
+	var (
+		f        float64
+		min, max = schema.Minimum, schema.Maximum
+		m        = schema.MultipleOf
+	)
+	if min != nil && f <= *min {
+		f = *min
+		if schema.ExclusiveMinimum {
+			f++
+		}
+	}
+	if m != nil && *m > 0 {
+		f = math.Ceil(f / *m) * *m
+	}
+	if max != nil && f >= *max {
+		f = *max
+		if schema.ExclusiveMaximum {
+			f--
+		}
+		if m != nil && *m > 0 {
+			f = math.Floor(f / *m) * *m
+		}
+		// Use the middle of the bounds when
+		// the range is narrower than a unit.
+		if min != nil && f <= *min {
+			f = (*min + *max) / 2
+		}
+	}
+	return f
+}
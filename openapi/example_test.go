@@ -0,0 +1,45 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerateExample tests that the generated examples
+// conform to the bounds and the patterns of the schemas.
+func TestGenerateExample(t *testing.T) {
+	for _, tt := range []struct {
+		schema *Schema
+		want   interface{}
+	}{
+		{&Schema{Type: "integer", Minimum: float64Ptr(0.5)}, int64(1)},
+		{&Schema{Type: "integer", Minimum: float64Ptr(-3.5)}, int64(0)},
+		{&Schema{Type: "integer", Minimum: float64Ptr(2), ExclusiveMinimum: true}, int64(3)},
+		{&Schema{Type: "integer", Minimum: float64Ptr(2.5), ExclusiveMinimum: true}, int64(3)},
+		{&Schema{Type: "integer", Maximum: float64Ptr(-0.5)}, int64(-1)},
+		{&Schema{Type: "integer", Maximum: float64Ptr(-2), ExclusiveMaximum: true}, int64(-3)},
+		{&Schema{Type: "integer", Minimum: float64Ptr(5), MultipleOf: float64Ptr(4)}, int64(8)},
+		{&Schema{Type: "number", Minimum: float64Ptr(0.5)}, 0.5},
+		{&Schema{Type: "string", Pattern: "^[a-z]+$"}, "string"},
+		{&Schema{Type: "string", Pattern: "^[0-9]{3}$"}, nil},
+		{&Schema{Type: "string", Pattern: "[a-z"}, nil},
+		{&Schema{Type: "string", Pattern: "^[0-9]{3}$", Example: "123"}, "123"},
+	} {
+		v := GenerateExample(nil, &SchemaOrRef{Schema: tt.schema})
+		assert.Equal(t, tt.want, v)
+
+		if v != nil {
+			assert.Empty(t, ValidateValue(nil, &SchemaOrRef{Schema: tt.schema}, toJSONNumber(v), ""))
+		}
+	}
+}
+
+// toJSONNumber converts the integers to float64,
+// as decoded by the encoding/json package.
+func toJSONNumber(v interface{}) interface{} {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v
+}
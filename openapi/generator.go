@@ -1,6 +1,7 @@
 package openapi
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -15,12 +16,16 @@ import (
 )
 
 const (
-	version              = "3.0.1"
 	anyMediaType         = "*/*"
 	formatTag            = "format"
 	deprecatedTag        = "deprecated"
 	descriptionTag       = "description"
+	contentTypeTag       = "contentType"
+	extensionsTag        = "openapi"
 	componentsSchemaPath = "#/components/schemas/"
+
+	multipartMediaType  = "multipart/form-data"
+	urlencodedMediaType = "application/x-www-form-urlencoded"
 )
 
 var (
@@ -35,18 +40,37 @@ var mediaTags = map[string]string{
 	"application/xml":  "xml",
 }
 
+// schemaKey identifies the schema of a type generated
+// with the serialization tag of a media type.
+type schemaKey struct {
+	t   reflect.Type
+	tag string
+}
+
 // Generator is an OpenAPI 3 generator.
 type Generator struct {
 	api           *OpenAPI
 	config        *SpecGenConfig
-	schemaTypes   map[reflect.Type]struct{}
+	schemaTypes   map[schemaKey]string
+	mediaTags     map[string]string
+	validators    map[string]ValidatorFunc
+	mediaType     string
+	consumes      []string
+	formInput     bool
 	typeNames     map[reflect.Type]string
+	schemaNames   map[string]reflect.Type
+	collisions    map[reflect.Type]struct{}
+	inlining      map[schemaKey]struct{}
 	dataTypes     map[reflect.Type]*OverridedDataType
+	polymorphics  map[reflect.Type]*polymorphicType
 	operationsIDS map[string]struct{}
 	errors        []error
-	fullNames     bool
+	naming        NamingStrategy
+	namer         SchemaNamer
+	genericNamer  GenericTypeNamer
 	sortParams    bool
 	sortTags      bool
+	disambiguate  bool
 }
 
 // NewGenerator returns a new OpenAPI generator.
@@ -54,6 +78,14 @@ func NewGenerator(conf *SpecGenConfig) (*Generator, error) {
 	if conf == nil {
 		return nil, errors.New("missing config")
 	}
+	tags := make(map[string]string, len(mediaTags))
+	for mt, tag := range mediaTags {
+		tags[mt] = tag
+	}
+	vfuncs := make(map[string]ValidatorFunc, len(validators))
+	for tag, fn := range validators {
+		vfuncs[tag] = fn
+	}
 	components := &Components{
 		Schemas:    make(map[string]*SchemaOrRef),
 		Responses:  make(map[string]*ResponseOrRef),
@@ -63,21 +95,36 @@ func NewGenerator(conf *SpecGenConfig) (*Generator, error) {
 	return &Generator{
 		config: conf,
 		api: &OpenAPI{
-			OpenAPI:    version,
+			OpenAPI:    Version30,
 			Info:       &Info{},
 			Paths:      make(Paths),
 			Components: components,
 		},
-		schemaTypes:   make(map[reflect.Type]struct{}),
+		schemaTypes:   make(map[schemaKey]string),
+		mediaTags:     tags,
+		validators:    vfuncs,
 		typeNames:     make(map[reflect.Type]string),
+		schemaNames:   make(map[string]reflect.Type),
+		collisions:    make(map[reflect.Type]struct{}),
+		inlining:      make(map[schemaKey]struct{}),
 		dataTypes:     make(map[reflect.Type]*OverridedDataType),
+		polymorphics:  make(map[reflect.Type]*polymorphicType),
 		operationsIDS: make(map[string]struct{}),
-		fullNames:     true,
+		naming:        PackageNames,
+		genericNamer:  DefaultGenericTypeNamer,
 		sortParams:    true,
 		sortTags:      true,
 	}, nil
 }
 
+// Default names of the tags used to declare the
+// cookie parameters and the fields of a form request
+// body, which are not defined by tonic.
+const (
+	CookieTag = "cookie"
+	FormTag   = "form"
+)
+
 // SpecGenConfig represents the configuration
 // of the spec generator.
 type SpecGenConfig struct {
@@ -88,8 +135,16 @@ type SpecGenConfig struct {
 	PathLocationTag   string
 	QueryLocationTag  string
 	HeaderLocationTag string
+	CookieLocationTag string
 	EnumTag           string
 	DefaultTag        string
+	// Name of the tag used to declare the fields
+	// of a form request body. Leave empty to only
+	// consider the fields of type multipart.FileHeader.
+	// The tag is only considered for the inputs that
+	// have a file field, or for the operations that
+	// explicitly consume a form media type.
+	FormTag string
 }
 
 // SetInfo uses the given OpenAPI info for the
@@ -116,8 +171,23 @@ func (g *Generator) SetSecuritySchemes(security map[string]*SecuritySchemeOrRef)
 	g.api.Components.SecuritySchemes = security
 }
 
+// SetVersion sets the version of the OpenAPI specification
+// returned by the generator. The supported versions are
+// Version30, which is the default, and Version31.
+func (g *Generator) SetVersion(version string) error {
+	switch version {
+	case Version30, Version31:
+		g.api.OpenAPI = version
+		return nil
+	}
+	return fmt.Errorf("unsupported OpenAPI version %s", version)
+}
+
 // API returns a copy of the internal OpenAPI object.
 func (g *Generator) API() *OpenAPI {
+	if g.api.OpenAPI == Version31 {
+		return convertTo31(g.api)
+	}
 	cpy := *g.api
 	return &cpy
 }
@@ -136,7 +206,44 @@ func (g *Generator) Errors() []error {
 // names are used across all the packages of the application.
 // Default to true.
 func (g *Generator) UseFullSchemaNames(b bool) {
-	g.fullNames = b
+	if b {
+		g.naming = PackageNames
+	} else {
+		g.naming = ShortNames
+	}
+}
+
+// SetNamingStrategy sets the strategy used to name
+// the component schemas. Default to PackageNames.
+func (g *Generator) SetNamingStrategy(s NamingStrategy) {
+	g.naming = s
+}
+
+// SetSchemaNamer sets a custom SchemaNamer used to name
+// the component schemas, instead of the naming strategy.
+// The names overrided with OverrideTypeName or the Typer
+// interface still have precedence. A nil namer restores
+// the naming strategy.
+func (g *Generator) SetSchemaNamer(n SchemaNamer) {
+	g.namer = n
+}
+
+// SetGenericTypeNamer sets the function used to name
+// the instantiations of the generic types. A nil function
+// restores the default, DefaultGenericTypeNamer.
+func (g *Generator) SetGenericTypeNamer(fn GenericTypeNamer) {
+	if fn == nil {
+		fn = DefaultGenericTypeNamer
+	}
+	g.genericNamer = fn
+}
+
+// SetDisambiguateNames controls whether the generator
+// should append a numeric suffix to the component schema
+// names already used by another type, such as Item2,
+// instead of reporting a NameCollisionError.
+func (g *Generator) SetDisambiguateNames(b bool) {
+	g.disambiguate = b
 }
 
 // SetSortParams controls whether the generator should
@@ -146,6 +253,26 @@ func (g *Generator) SetSortParams(b bool) {
 	g.sortParams = b
 }
 
+// SetMediaTypeTag sets the name of the struct tag used
+// to name the properties of the schemas generated for
+// the media type mt, such as csv for text/csv.
+func (g *Generator) SetMediaTypeTag(mt, tag string) {
+	g.mediaTags[mt] = tag
+}
+
+// RegisterValidator registers the translation of a tag of
+// the validator package, such as a custom validator, to the
+// keywords of the schemas. The translation of a built-in tag
+// can be overrided, and a nil function ignores the tag.
+func (g *Generator) RegisterValidator(tag string, fn ValidatorFunc) error {
+	if tag == "" {
+		return errors.New("validator tag is empty")
+	}
+	g.validators[tag] = fn
+
+	return nil
+}
+
 // SetSortTags controls whether the generator should
 // sort the global tags sections.
 func (g *Generator) SetSortTags(b bool) {
@@ -191,6 +318,57 @@ func (g *Generator) OverrideDataType(t reflect.Type, typ, format string) error {
 	return nil
 }
 
+// RegisterOneOf registers the implementations of the
+// interface type t, which will be described as a schema
+// that must validate exactly one of the implementations
+// schemas. The keys of the mapping are the values of the
+// discriminator property that identify each implementation.
+// If the property name is empty, no discriminator is set.
+func (g *Generator) RegisterOneOf(t reflect.Type, propertyName string, mapping map[string]interface{}) error {
+	return g.registerPolymorphicType(t, "oneOf", propertyName, mapping)
+}
+
+// RegisterAnyOf registers the implementations of the
+// interface type t, which will be described as a schema
+// that must validate at least one of the implementations
+// schemas. See RegisterOneOf for details about the mapping.
+func (g *Generator) RegisterAnyOf(t reflect.Type, propertyName string, mapping map[string]interface{}) error {
+	return g.registerPolymorphicType(t, "anyOf", propertyName, mapping)
+}
+
+func (g *Generator) registerPolymorphicType(t reflect.Type, kind, propertyName string, mapping map[string]interface{}) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Interface {
+		return fmt.Errorf("type %s is not an interface", t)
+	}
+	if len(mapping) == 0 {
+		return errors.New("mapping is empty")
+	}
+	if _, ok := g.polymorphics[t]; ok {
+		return fmt.Errorf("implementations of type %s already registered", t)
+	}
+	pt := &polymorphicType{
+		kind:         kind,
+		propertyName: propertyName,
+		mapping:      make(map[string]reflect.Type, len(mapping)),
+	}
+	for k, v := range mapping {
+		it := reflect.TypeOf(v)
+		if it == nil {
+			return fmt.Errorf("nil implementation for key %s", k)
+		}
+		if !it.Implements(t) && !reflect.PtrTo(it).Implements(t) {
+			return fmt.Errorf("type %s does not implement %s", it, t)
+		}
+		pt.mapping[k] = it
+	}
+	g.polymorphics[t] = pt
+
+	return nil
+}
+
 func (g *Generator) datatype(t reflect.Type) DataType {
 	if dt, ok := g.dataTypes[t]; ok {
 		return dt
@@ -198,6 +376,77 @@ func (g *Generator) datatype(t reflect.Type) DataType {
 	return DataTypeFromType(t)
 }
 
+// RegisterParameter registers a reusable parameter with
+// the given name in the components of the specification.
+// If the parameter has no schema, it is generated from the
+// type of the model, which default to string. The parameter
+// is copied, and can be reused by the caller.
+func (g *Generator) RegisterParameter(name string, p *Parameter, model interface{}) error {
+	if name == "" {
+		return errors.New("parameter name is empty")
+	}
+	if p == nil || p.Name == "" || p.In == "" {
+		return errors.New("parameter name and location are required")
+	}
+	if _, ok := g.api.Components.Parameters[name]; ok {
+		return fmt.Errorf("parameter %s is already registered", name)
+	}
+	cpy := *p
+	if cpy.Schema == nil {
+		cpy.Schema = g.newSchemaFromModel(model)
+	}
+	g.api.Components.Parameters[name] = &ParameterOrRef{Parameter: &cpy}
+
+	return nil
+}
+
+// RegisterResponse registers a reusable response with
+// the given name in the components of the specification.
+// The code of the response is only used to set a default
+// description.
+func (g *Generator) RegisterResponse(name string, resp *OperationResponse) error {
+	if name == "" {
+		return errors.New("response name is empty")
+	}
+	if resp == nil {
+		return errors.New("response is nil")
+	}
+	if _, ok := g.api.Components.Responses[name]; ok {
+		return fmt.Errorf("response %s is already registered", name)
+	}
+	desc := resp.Description
+	if ci, err := strconv.Atoi(resp.Code); err == nil && desc == "" {
+		desc = http.StatusText(ci)
+	}
+	r, err := g.newResponse(reflect.TypeOf(resp.Model), []string{tonic.MediaType()}, desc, resp.Headers, resp.Example, resp.Examples)
+	if err != nil {
+		return err
+	}
+	g.api.Components.Responses[name] = &ResponseOrRef{Response: r}
+
+	return nil
+}
+
+// RegisterHeader registers a reusable header with the
+// given name in the components of the specification.
+// The name of the response header is ignored.
+func (g *Generator) RegisterHeader(name string, h *ResponseHeader) error {
+	if name == "" {
+		return errors.New("header name is empty")
+	}
+	if h == nil {
+		return errors.New("header is nil")
+	}
+	if _, ok := g.api.Components.Headers[name]; ok {
+		return fmt.Errorf("header %s is already registered", name)
+	}
+	g.api.Components.Headers[name] = &HeaderOrRef{Header: &Header{
+		Description: h.Description,
+		Schema:      g.newSchemaFromModel(h.Model),
+	}}
+	return nil
+}
+
 // AddTag adds a new tag to the OpenAPI specification.
 // If a tag already exists with the same name, it is
 // overwritten.
@@ -231,6 +480,31 @@ func (g *Generator) AddTag(name, desc string) {
 	}
 }
 
+// AddTagGroup adds the tags to the group of tags of the
+// x-tagGroups extension with the given name. The group is
+// created if it doesn't exist yet.
+func (g *Generator) AddTagGroup(name string, tags ...string) {
+	if name == "" {
+		return
+	}
+	var group *XTagGroup
+	for _, tg := range g.api.XTagGroups {
+		if tg != nil && tg.Name == name {
+			group = tg
+			break
+		}
+	}
+	if group == nil {
+		group = &XTagGroup{Name: name}
+		g.api.XTagGroups = append(g.api.XTagGroups, group)
+	}
+	for _, tag := range tags {
+		if tag != "" && !containsString(group.Tags, tag) {
+			group.Tags = append(group.Tags, tag)
+		}
+	}
+}
+
 // AddOperation add a new operation to the OpenAPI specification
 // using the method and path of the route and the tonic
 // handler informations.
@@ -245,6 +519,11 @@ func (g *Generator) AddOperation(path, method, tag string, in, out reflect.Type,
 		if _, ok := g.operationsIDS[info.ID]; ok {
 			return nil, fmt.Errorf("ID %s is already used by another operation", info.ID)
 		}
+		for k := range info.Extensions {
+			if err := checkExtension(op, k); err != nil {
+				return nil, err
+			}
+		}
 		g.operationsIDS[info.ID] = struct{}{}
 	}
 	// If a PathItem does not exists for this
@@ -265,10 +544,31 @@ func (g *Generator) AddOperation(path, method, tag string, in, out reflect.Type,
 		op.XCodeSamples = info.XCodeSamples
 		op.Security = info.Security
 		op.XInternal = info.XInternal
+		op.Extensions = info.Extensions
 	}
 	if tag != "" {
 		op.Tags = append(op.Tags, tag)
 	}
+	if info != nil {
+		for _, t := range info.Tags {
+			if t != "" && !containsString(op.Tags, t) {
+				op.Tags = append(op.Tags, t)
+			}
+		}
+	}
+	// Add the references to the parameters registered
+	// in the components before the input parameters, to
+	// detect the duplicates.
+	if info != nil {
+		for _, name := range info.ParameterRefs {
+			if _, ok := g.api.Components.Parameters[name]; !ok {
+				return nil, fmt.Errorf("unknown parameter component %s", name)
+			}
+			op.Parameters = append(op.Parameters, &ParameterOrRef{Reference: &Reference{
+				Ref: componentsParameterPath + name,
+			}})
+		}
+	}
 	// Operations with methods GET/HEAD/DELETE cannot have a body.
 	// Non parameters fields will be ignored.
 	allowBody := method != http.MethodGet &&
@@ -282,24 +582,38 @@ func (g *Generator) AddOperation(path, method, tag string, in, out reflect.Type,
 		if in.Kind() != reflect.Struct {
 			return nil, errors.New("input type is not a struct")
 		}
-		if err := g.setOperationParams(op, in, in, allowBody, path); err != nil {
+		g.consumes = info.Consumes
+		g.formInput = isFormInput(in, info.Consumes)
+		err := g.setOperationParams(op, in, in, allowBody, path)
+		g.consumes, g.formInput = nil, false
+		if err != nil {
 			return nil, err
 		}
+	} else if g.sortParams {
+		// The references to the parameters of the
+		// components are sorted like the parameters
+		// of the input type.
+		g.sortParameters(op)
 	}
 	// Generate the default response from the tonic
 	// handler return type. If the handler has no output
 	// type, the response won't have a schema.
-	if err := g.setOperationResponse(op, out, strconv.Itoa(info.StatusCode), tonic.MediaType(), info.StatusDescription, info.Headers, nil, nil); err != nil {
+	code := strconv.Itoa(info.StatusCode)
+	if err := g.setOperationResponse(op, out, code, responseMediaTypes(info, code), info.StatusDescription, info.Headers, nil, nil); err != nil {
 		return nil, err
 	}
 	// Generate additional responses from the operation
 	// informations.
 	for _, resp := range info.Responses {
-		if resp != nil {
+		if resp != nil && resp.Ref != "" {
+			if err := g.setOperationResponseRef(op, resp.Code, resp.Ref); err != nil {
+				return nil, err
+			}
+		} else if resp != nil {
 			if err := g.setOperationResponse(op,
 				reflect.TypeOf(resp.Model),
 				resp.Code,
-				tonic.MediaType(),
+				responseMediaTypes(info, resp.Code),
 				resp.Description,
 				resp.Headers,
 				resp.Example,
@@ -314,6 +628,20 @@ func (g *Generator) AddOperation(path, method, tag string, in, out reflect.Type,
 	return op, nil
 }
 
+// responseMediaTypes returns the media types of the
+// response with the given code, which default to the
+// media types produced by the operation, or the media
+// type of tonic.
+func responseMediaTypes(info *OperationInfo, code string) []string {
+	if mts := info.ResponseMediaTypes[code]; len(mts) != 0 {
+		return mts
+	}
+	if len(info.Produces) != 0 {
+		return info.Produces
+	}
+	return []string{tonic.MediaType()}
+}
+
 // rewritePath converts a Gin operation path that use
 // colons and asterisks to declare path parameters, to
 // an OpenAPI representation that use curly braces.
@@ -321,6 +649,48 @@ func rewritePath(path string) string {
 	return ginPathParamRe.ReplaceAllString(path, "/{$1}")
 }
 
+// HasOperationID returns whether the ID is
+// already used by an operation of the spec.
+func (g *Generator) HasOperationID(id string) bool {
+	_, ok := g.operationsIDS[id]
+	return ok
+}
+
+// Operation returns the operation registered for the
+// given path and method, or nil if none is found. The
+// path can use the Gin syntax to declare parameters.
+func (g *Generator) Operation(path, method string) *Operation {
+	item, ok := g.api.Paths[rewritePath(path)]
+	if !ok {
+		return nil
+	}
+	return item.Operation(method)
+}
+
+// Operation returns the operation of the path
+// item that correspond to the given method.
+func (item *PathItem) Operation(method string) *Operation {
+	switch method {
+	case "GET":
+		return item.GET
+	case "PUT":
+		return item.PUT
+	case "POST":
+		return item.POST
+	case "PATCH":
+		return item.PATCH
+	case "HEAD":
+		return item.HEAD
+	case "OPTIONS":
+		return item.OPTIONS
+	case "TRACE":
+		return item.TRACE
+	case "DELETE":
+		return item.DELETE
+	}
+	return nil
+}
+
 // setOperationBymethod sets the operation op to the appropriate
 // field of item according to the given method.
 func setOperationBymethod(item *PathItem, op *Operation, method string) {
@@ -361,8 +731,8 @@ func isResponseCodeRange(code string) bool {
 }
 
 // setOperationResponse adds a response to the operation that
-// return the type t with the given media type and status code.
-func (g *Generator) setOperationResponse(op *Operation, t reflect.Type, code, mt, desc string, headers []*ResponseHeader, example interface{}, examples map[string]interface{}) error {
+// return the type t with the given media types and status code.
+func (g *Generator) setOperationResponse(op *Operation, t reflect.Type, code string, mts []string, desc string, headers []*ResponseHeader, example interface{}, examples map[string]interface{}) error {
 	if _, ok := op.Responses[code]; ok {
 		// A response already exists for this code.
 		return fmt.Errorf("response with code %s already exists", code)
@@ -390,6 +760,35 @@ func (g *Generator) setOperationResponse(op *Operation, t reflect.Type, code, mt
 			}
 		}
 	}
+	r, err := g.newResponse(t, mts, desc, headers, example, examples)
+	if err != nil {
+		return err
+	}
+	op.Responses[code] = &ResponseOrRef{Response: r}
+
+	return nil
+}
+
+// setOperationResponseRef adds a reference to the response
+// registered in the components with the given name to the
+// operation.
+func (g *Generator) setOperationResponseRef(op *Operation, code, name string) error {
+	if _, ok := op.Responses[code]; ok {
+		// A response already exists for this code.
+		return fmt.Errorf("response with code %s already exists", code)
+	}
+	if _, ok := g.api.Components.Responses[name]; !ok {
+		return fmt.Errorf("unknown response component %s", name)
+	}
+	op.Responses[code] = &ResponseOrRef{Reference: &Reference{
+		Ref: componentsResponsePath + name,
+	}}
+	return nil
+}
+
+// newResponse returns a new response that return the
+// type t with the given media types.
+func (g *Generator) newResponse(t reflect.Type, mts []string, desc string, headers []*ResponseHeader, example interface{}, examples map[string]interface{}) (*Response, error) {
 	r := &Response{
 		Description: desc,
 		Content:     make(map[string]*MediaTypeOrRef),
@@ -404,36 +803,68 @@ func (g *Generator) setOperationResponse(op *Operation, t reflect.Type, code, mt
 		}
 	}
 
-	// The response may have no content type specified,
-	// in which case we don't assign a schema.
-	schema := g.newSchemaFromType(t)
+	// Each media type has its own schema, whose properties
+	// are named with the serialization tag of the media type.
+	for _, mt := range mts {
+		// The response may have no content type specified,
+		// in which case we don't assign a schema.
+		g.mediaType = mt
+		schema := g.newSchemaFromType(t)
+		g.mediaType = ""
+
+		if schema != nil || example != nil || castedExamples != nil {
+			r.Content[mt] = &MediaTypeOrRef{MediaType: &MediaType{
+				Schema:   schema,
+				Example:  example,
+				Examples: castedExamples,
+			}}
+		}
+	}
+	// The examples must satisfy the constraints of the
+	// schema of the media types that use the default
+	// serialization tag, such as JSON.
+	for _, mt := range mts {
+		media, ok := r.Content[mt]
+		if !ok || media.Schema == nil || g.tagSuffix(mt) != "" {
+			continue
+		}
+		g.checkValue(media.Schema, example, g.exampleError("example", t))
 
-	if schema != nil || example != nil || castedExamples != nil {
-		r.Content[mt] = &MediaTypeOrRef{MediaType: &MediaType{
-			Schema:   schema,
-			Example:  example,
-			Examples: castedExamples,
-		}}
+		for _, name := range SortedKeys(examples) {
+			g.checkValue(media.Schema, examples[name], g.exampleError(name, t))
+		}
+		break
 	}
 	// Assign headers.
 	for _, h := range headers {
-		if h != nil {
-			var sor *SchemaOrRef
-			if h.Model == nil {
-				// default to string if no type is given.
-				sor = &SchemaOrRef{Schema: &Schema{Type: "string"}}
-			} else {
-				sor = g.newSchemaFromType(reflect.TypeOf(h.Model))
+		if h == nil {
+			continue
+		}
+		if h.Ref != "" {
+			if _, ok := g.api.Components.Headers[h.Ref]; !ok {
+				return nil, fmt.Errorf("unknown header component %s", h.Ref)
 			}
-			r.Headers[h.Name] = &HeaderOrRef{Header: &Header{
-				Description: h.Description,
-				Schema:      sor,
+			r.Headers[h.Name] = &HeaderOrRef{Reference: &Reference{
+				Ref: componentsHeaderPath + h.Ref,
 			}}
+			continue
 		}
+		r.Headers[h.Name] = &HeaderOrRef{Header: &Header{
+			Description: h.Description,
+			Schema:      g.newSchemaFromModel(h.Model),
+		}}
 	}
-	op.Responses[code] = &ResponseOrRef{Response: r}
+	return r, nil
+}
 
-	return nil
+// newSchemaFromModel returns a new schema from the
+// type of the model, which default to string if the
+// model is nil.
+func (g *Generator) newSchemaFromModel(model interface{}) *SchemaOrRef {
+	if model == nil {
+		return &SchemaOrRef{Schema: &Schema{Type: "string"}}
+	}
+	return g.newSchemaFromType(reflect.TypeOf(model))
 }
 
 // setOperationParams adds the fields of the struct type t
@@ -452,15 +883,30 @@ func (g *Generator) setOperationParams(op *Operation, t, parent reflect.Type, al
 	// Replace the RequestBody's schema with a reference
 	// to the named schema in components/schemas
 	if op.RequestBody != nil {
-		mt := tonic.MediaType()
-		if mt == "" {
-			mt = anyMediaType
-		}
-		sch := op.RequestBody.Content[mt].Schema
-		if sch != nil {
-			name := strings.Title(op.ID) + "Input"
-			g.api.Components.Schemas[name] = sch
-			op.RequestBody.Content[mt].Schema = &SchemaOrRef{Reference: &Reference{
+		g.setFormMediaType(op)
+
+		for mt, media := range op.RequestBody.Content {
+			if media.Schema == nil {
+				continue
+			}
+			// The schemas of the media types with a non-default
+			// serialization tag are suffixed with the tag, and
+			// the form body is suffixed to avoid a conflict when
+			// the input also has a default body.
+			name := strings.Title(op.ID) + "Input" + g.tagSuffix(mt)
+
+			if (mt == multipartMediaType || mt == urlencodedMediaType) && len(op.RequestBody.Content) > 1 {
+				name = strings.Title(op.ID) + "FormInput"
+			}
+			// The name of the schema is owned by the input
+			// type, and the schema is inlined if the name is
+			// already used by another type.
+			name, ok := g.registerSchemaName(name, t)
+			if !ok {
+				continue
+			}
+			g.api.Components.Schemas[name] = media.Schema
+			media.Schema = &SchemaOrRef{Reference: &Reference{
 				Ref: componentsSchemaPath + name,
 			}}
 		}
@@ -475,8 +921,8 @@ func (g *Generator) setOperationParams(op *Operation, t, parent reflect.Type, al
 	// defined in the operation.
 	for _, pp := range pathParams {
 		has := false
-		for _, param := range op.Parameters {
-			if param.In == "path" && param.Name == pp {
+		for _, por := range op.Parameters {
+			if param := g.resolveParameter(por); param != nil && param.In == "path" && param.Name == pp {
 				has = true
 				break
 			}
@@ -488,14 +934,20 @@ func (g *Generator) setOperationParams(op *Operation, t, parent reflect.Type, al
 	// Sort operations parameters by location and name
 	// in ascending order.
 	if g.sortParams {
-		paramsOrderedBy(
-			g.paramyByLocation,
-			g.paramyByName,
-		).Sort(op.Parameters)
+		g.sortParameters(op)
 	}
 	return nil
 }
 
+// sortParameters sorts the parameters of the
+// operation by location and name.
+func (g *Generator) sortParameters(op *Operation) {
+	paramsOrderedBy(
+		g.paramyByLocation,
+		g.paramyByName,
+	).Sort(op.Parameters)
+}
+
 func (g *Generator) buildParamsRecursive(op *Operation, t, parent reflect.Type, allowBody bool) error {
 	for i := 0; i < t.NumField(); i++ {
 		sf := t.Field(i)
@@ -565,8 +1017,8 @@ func (g *Generator) addStructFieldToOperation(op *Operation, t reflect.Type, idx
 	if param != nil {
 		// Check if a parameter with same name/location
 		// already exists.
-		for _, p := range op.Parameters {
-			if p != nil && (p.Name == param.Name) && (p.In == param.In) {
+		for _, por := range op.Parameters {
+			if p := g.resolveParameter(por); p != nil && (p.Name == param.Name) && (p.In == param.In) {
 				g.error(&FieldError{
 					Message:           "duplicate parameter",
 					Name:              param.Name,
@@ -599,55 +1051,178 @@ func (g *Generator) addStructFieldToOperation(op *Operation, t reflect.Type, idx
 				Content: make(map[string]*MediaType),
 			}
 		}
-		// Select the corresponding media type for the
-		// given field tag, or default to any type.
-		mt := tonic.MediaType()
-		if mt == "" {
-			mt = anyMediaType
+		// Form fields are grouped in a multipart body,
+		// which may be changed to an url-encoded body
+		// once all the fields are known.
+		if g.isFormField(sf) {
+			g.addRequestBodyField(op, t, sf, multipartMediaType, g.config.FormTag)
+			return nil
+		}
+		// Add the field to the body of each media type
+		// consumed by the operation, using the media type
+		// serialization tag to name the property.
+		for _, mt := range g.requestMediaTypes() {
+			g.mediaType = mt
+			g.addRequestBodyField(op, t, sf, mt, g.mediaTag())
+			g.mediaType = ""
 		}
-		var schema *Schema
+	}
+	return nil
+}
 
-		// Create the media type if no fields
-		// have been added yet.
-		if _, ok := op.RequestBody.Content[mt]; !ok {
-			schema = &Schema{
-				Type:       "object",
-				Properties: make(map[string]*SchemaOrRef),
-			}
-			op.RequestBody.Content[mt] = &MediaType{
-				Schema: &SchemaOrRef{Schema: schema},
-			}
-		} else {
-			schema = op.RequestBody.Content[mt].Schema.Schema
+// requestMediaTypes returns the media types of the
+// request body of the operation being generated, which
+// default to the media type of tonic, or any type.
+func (g *Generator) requestMediaTypes() []string {
+	if len(g.consumes) != 0 {
+		return g.consumes
+	}
+	if mt := tonic.MediaType(); mt != "" {
+		return []string{mt}
+	}
+	return []string{anyMediaType}
+}
+
+// addRequestBodyField adds the struct field sf of the
+// type t to the schema of the request body with the
+// media type mt, using the tag to name the property.
+func (g *Generator) addRequestBodyField(op *Operation, t reflect.Type, sf reflect.StructField, mt, tag string) {
+	var schema *Schema
+
+	// Create the media type if no fields
+	// have been added yet.
+	if _, ok := op.RequestBody.Content[mt]; !ok {
+		schema = &Schema{
+			Type:       "object",
+			Properties: make(map[string]*SchemaOrRef),
+		}
+		op.RequestBody.Content[mt] = &MediaType{
+			Schema: &SchemaOrRef{Schema: schema},
 		}
-		fname := fieldNameFromTag(sf, mediaTags[tonic.MediaType()])
+	} else {
+		schema = op.RequestBody.Content[mt].Schema.Schema
+	}
+	fname := fieldNameFromTag(sf, tag)
+
+	// Check if a field with the same name already exists.
+	if _, ok := schema.Properties[fname]; ok {
+		g.error(&FieldError{
+			Message:           "duplicate request body parameter",
+			Name:              fname,
+			TypeName:          g.typeName(t),
+			Type:              t,
+			ParameterLocation: "body",
+		})
+		return
+	}
 
-		// Check if a field with the same name already exists.
-		if _, ok := schema.Properties[fname]; ok {
-			g.error(&FieldError{
-				Message:           "duplicate request body parameter",
-				Name:              fname,
-				TypeName:          g.typeName(t),
-				Type:              t,
-				ParameterLocation: "body",
-			})
-			return nil
+	var required bool
+	// The required property of a field is not part of its
+	// own schema but specified in the parent schema.
+	if fname != "" && g.isStructFieldRequired(sf) {
+		required = true
+		schema.Required = append(schema.Required, fname)
+		sort.Strings(schema.Required)
+	}
+	sfs := g.newSchemaFromStructField(sf, required, fname, t)
+	if schema != nil {
+		schema.Properties[fname] = sfs
+	}
+	// Per-part content type of a multipart body.
+	if ct := sf.Tag.Get(contentTypeTag); mt == multipartMediaType && ct != "" {
+		media := op.RequestBody.Content[mt]
+		if media.Encoding == nil {
+			media.Encoding = make(map[string]*Encoding)
 		}
+		media.Encoding[fname] = &Encoding{ContentType: ct}
+	}
+}
 
-		var required bool
-		// The required property of a field is not part of its
-		// own schema but specified in the parent schema.
-		if fname != "" && g.isStructFieldRequired(sf) {
-			required = true
-			schema.Required = append(schema.Required, fname)
-			sort.Strings(schema.Required)
+// isFormField returns whether the struct field is
+// part of a form request body. A field of type
+// multipart.FileHeader is always a form field, while
+// the form tag is only considered if the input of the
+// operation is a form.
+func (g *Generator) isFormField(sf reflect.StructField) bool {
+	if g.config.FormTag != "" && g.formInput {
+		if _, ok := sf.Tag.Lookup(g.config.FormTag); ok {
+			return true
 		}
-		sfs := g.newSchemaFromStructField(sf, required, fname, t)
-		if schema != nil {
-			schema.Properties[fname] = sfs
+	}
+	return isFileType(sf.Type)
+}
+
+// isFormInput returns whether the input type t of an
+// operation is a form, that is if it has a file field,
+// or if the operation explicitly consumes a form media
+// type. Otherwise, the form tag is commonly used with
+// the json tag for the binding of gin, and the input
+// is a JSON body.
+func isFormInput(t reflect.Type, consumes []string) bool {
+	for _, mt := range consumes {
+		if mt == multipartMediaType || mt == urlencodedMediaType {
+			return true
 		}
 	}
-	return nil
+	return hasFileField(t)
+}
+
+// hasFileField returns whether the struct type t,
+// or one of its embedded structs, has a file field.
+func hasFileField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if isFileType(f.Type) {
+			return true
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && ft != t && hasFileField(ft) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFileType returns whether the type t is a
+// multipart.FileHeader, or a slice of it.
+func isFileType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t == tofFileHeader
+}
+
+// setFormMediaType replaces the multipart media type
+// of the operation request body by an url-encoded one
+// if none of the properties of its schema are files,
+// unless the operation consumes multipart bodies.
+func (g *Generator) setFormMediaType(op *Operation) {
+	media, ok := op.RequestBody.Content[multipartMediaType]
+	if !ok || media.Schema == nil || media.Schema.Schema == nil {
+		return
+	}
+	// A multipart body explicitly consumed by
+	// the operation is kept as is.
+	if containsString(g.consumes, multipartMediaType) {
+		return
+	}
+	for _, prop := range media.Schema.Properties {
+		schema := g.resolveSchema(prop)
+		if schema == nil {
+			continue
+		}
+		if schema.Type == "array" && schema.Items != nil {
+			schema = g.resolveSchema(schema.Items)
+		}
+		if schema != nil && schema.Type == "string" && schema.Format == TypeBinary.Format() {
+			return
+		}
+	}
+	delete(op.RequestBody.Content, multipartMediaType)
+	op.RequestBody.Content[urlencodedMediaType] = media
 }
 
 // newParameterFromField create a new operation parameter
@@ -724,9 +1299,12 @@ func (g *Generator) paramLocation(f reflect.StructField, in reflect.Type) (strin
 		g.config.PathLocationTag,
 		g.config.QueryLocationTag,
 		g.config.HeaderLocationTag,
+		g.config.CookieLocationTag,
 	}
 	for i, n := range parameterLocations {
-		has(n, f.Tag, i)
+		if n != "" {
+			has(n, f.Tag, i)
+		}
 	}
 	if c == 0 {
 		// This will be considered to be part
@@ -816,6 +1394,25 @@ func (g *Generator) newSchemaFromStructField(sf reflect.StructField, required bo
 		schema.Format = t
 	}
 
+	// Vendor extensions.
+	if t, ok := sf.Tag.Lookup(extensionsTag); ok {
+		if ext, err := parseExtensions(t); err != nil {
+			g.error(&FieldError{
+				Message:  fmt.Sprintf("invalid extensions %q: %s", t, err),
+				Name:     fname,
+				Type:     sf.Type,
+				TypeName: g.typeName(sf.Type),
+				Parent:   parent,
+			})
+		} else if len(ext) != 0 {
+			if schema.Extensions == nil {
+				schema.Extensions = make(map[string]interface{}, len(ext))
+			}
+			for k, v := range ext {
+				schema.Extensions[k] = v
+			}
+		}
+	}
 	// Set example value from tag to schema
 	if e := strings.TrimSpace(sf.Tag.Get("example")); e != "" {
 		if parsed, err := parseExampleValue(sf.Type, e); err != nil {
@@ -830,10 +1427,63 @@ func (g *Generator) newSchemaFromStructField(sf reflect.StructField, required bo
 			schema.Example = parsed
 		}
 	}
-
+	// The default and example values must satisfy
+	// the constraints of the schema of the field.
+	fieldError := func(kind string) func(string) *FieldError {
+		return func(msg string) *FieldError {
+			return &FieldError{
+				Message:  fmt.Sprintf("%s value does not match the field schema: %s", kind, msg),
+				Name:     fname,
+				Type:     sf.Type,
+				TypeName: g.typeName(sf.Type),
+				Parent:   parent,
+			}
+		}
+	}
+	if sf.Tag.Get(g.config.DefaultTag) != "" {
+		g.checkValue(&SchemaOrRef{Schema: schema}, schema.Default, fieldError("default"))
+	}
+	if strings.TrimSpace(sf.Tag.Get("example")) != "" {
+		g.checkValue(&SchemaOrRef{Schema: schema}, schema.Example, fieldError("example"))
+	}
 	return sor
 }
 
+// checkValue validates the value v against the schema sor,
+// and reports each violation with the error returned by fn.
+// The value is converted to its JSON representation before
+// the validation.
+func (g *Generator) checkValue(sor *SchemaOrRef, v interface{}, fn func(msg string) *FieldError) {
+	if sor == nil || v == nil {
+		return
+	}
+	v, err := jsonValue(v)
+	if err != nil {
+		g.error(fn(err.Error()))
+		return
+	}
+	for _, ve := range ValidateValue(g.api, sor, v, "") {
+		msg := ve.Message
+		if ve.Pointer != "" {
+			msg = fmt.Sprintf("%s at %s", msg, ve.Pointer)
+		}
+		g.error(fn(msg))
+	}
+}
+
+// exampleError returns a function that builds the
+// errors of the named example of a response of type t.
+func (g *Generator) exampleError(name string, t reflect.Type) func(string) *FieldError {
+	return func(msg string) *FieldError {
+		return &FieldError{
+			Message:  fmt.Sprintf("response example does not match its schema: %s", msg),
+			Name:     name,
+			Type:     t,
+			TypeName: g.typeName(t),
+		}
+	}
+}
+
 func (g *Generator) enumFromStructField(sf reflect.StructField, fname string, parent reflect.Type) []interface{} {
 	var enum []interface{}
 
@@ -874,13 +1524,17 @@ func (g *Generator) newSchemaFromType(t reflect.Type) *SchemaOrRef {
 	// Dereference pointer.
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
-		nullable = true
+		// A file part of a form cannot be null.
+		nullable = t != tofFileHeader
 	} else if t.Implements(tofNullable) {
 		i, ok := reflect.New(t).Interface().(Nullable)
 		if ok {
 			nullable = i.Nullable()
 		}
 	}
+	if pt, ok := g.polymorphics[t]; ok {
+		return g.newSchemaFromPolymorphicType(t, pt)
+	}
 	dt := g.datatype(t)
 
 	if dt == TypeUnsupported {
@@ -917,12 +1571,21 @@ func (g *Generator) newSchemaFromType(t reflect.Type) *SchemaOrRef {
 // buildSchemaRecursive recursively decomposes the complex
 // type t into subsequent schemas.
 func (g *Generator) buildSchemaRecursive(t reflect.Type) *SchemaOrRef {
+	if pt, ok := g.polymorphics[t]; ok {
+		return g.newSchemaFromPolymorphicType(t, pt)
+	}
 	schema := &Schema{}
 
 	switch t.Kind() {
 	case reflect.Ptr:
 		return g.buildSchemaRecursive(t.Elem())
 	case reflect.Struct:
+		// The files of a form, such as the items
+		// of a slice of files, are binary strings.
+		if t == tofFileHeader {
+			schema.Type, schema.Format = TypeBinary.Type(), TypeBinary.Format()
+			break
+		}
 		return g.newSchemaFromStruct(t)
 	case reflect.Map:
 		// Map type is considered as a type "object"
@@ -964,17 +1627,21 @@ func (g *Generator) newSchemaFromStruct(t reflect.Type) *SchemaOrRef {
 	if t.Kind() != reflect.Struct {
 		return nil
 	}
-	name := g.typeName(t)
+	name := g.componentName(t)
+	key := schemaKey{t: t, tag: g.mediaTag()}
 
 	// If the type of the field has already been registered,
 	// skip the schema generation to avoid a recursive loop.
 	// We're not returning directly a reference from the components,
 	// because there is no guarantee the generation is complete yet.
-	if _, ok := g.schemaTypes[t]; ok {
+	if n, ok := g.schemaTypes[key]; ok {
 		return &SchemaOrRef{Reference: &Reference{
-			Ref: componentsSchemaPath + name,
+			Ref: componentsSchemaPath + n,
 		}}
 	}
+	if sor, ok := g.inliningSchema(key); ok {
+		return sor
+	}
 	schema := &Schema{
 		Type:       "object",
 		Properties: make(map[string]*SchemaOrRef),
@@ -982,8 +1649,15 @@ func (g *Generator) newSchemaFromStruct(t reflect.Type) *SchemaOrRef {
 	// Register the type once before diving into
 	// the recursive hole if it has a name. Anonymous
 	// struct are all considered unique.
+	registered := false
 	if name != "" {
-		g.schemaTypes[t] = struct{}{}
+		name, registered = g.registerSchemaName(name, t)
+		if !registered {
+			g.inlining[key] = struct{}{}
+			defer delete(g.inlining, key)
+		} else {
+			g.schemaTypes[key] = name
+		}
 	}
 	schema = g.flattenStructSchema(t, t, schema)
 
@@ -991,8 +1665,9 @@ func (g *Generator) newSchemaFromStruct(t reflect.Type) *SchemaOrRef {
 
 	// Register the schema within the speccomponents and return a
 	// relative reference. Unnamed types, like anonymous structs,
-	// will always be inlined in the specification.
-	if name != "" {
+	// will always be inlined in the specification, as well as the
+	// types whose name is already used by another type.
+	if registered {
 		g.api.Components.Schemas[name] = sor
 
 		return &SchemaOrRef{Reference: &Reference{
@@ -1003,6 +1678,185 @@ func (g *Generator) newSchemaFromStruct(t reflect.Type) *SchemaOrRef {
 	return sor
 }
 
+// registerSchemaName records the type t as the owner of
+// the component schema name, and returns the name to use.
+// If the name is already owned by another type, a numeric
+// suffix is appended when the names are disambiguated, or
+// a NameCollisionError is reported and false returned.
+func (g *Generator) registerSchemaName(name string, t reflect.Type) (string, bool) {
+	owner, ok := g.schemaNames[name]
+	if !ok || owner == t {
+		g.schemaNames[name] = t
+		return name, true
+	}
+	if g.disambiguate {
+		for i := 2; ; i++ {
+			n := name + strconv.Itoa(i)
+			if o, ok := g.schemaNames[n]; !ok || o == t {
+				g.schemaNames[n] = t
+				return n, true
+			}
+		}
+	}
+	// The losing type is inlined each time it is used,
+	// but the collision is reported only once.
+	if _, ok := g.collisions[t]; !ok {
+		g.collisions[t] = struct{}{}
+		g.error(&NameCollisionError{
+			Name:  name,
+			Type:  t,
+			Owner: owner,
+		})
+	}
+	return name, false
+}
+
+// inliningSchema returns the schema of a recursive reference
+// to a type that is being inlined because its name is owned
+// by another type. Such a reference cannot be expressed in
+// the spec, and is replaced by a schema that accepts any
+// value.
+func (g *Generator) inliningSchema(key schemaKey) (*SchemaOrRef, bool) {
+	if _, ok := g.inlining[key]; !ok {
+		return nil, false
+	}
+	return &SchemaOrRef{Schema: &Schema{}}, true
+}
+
+// newSchemaFromPolymorphicType returns an OpenAPI schema
+// that describes the registered implementations of the
+// interface type t.
+func (g *Generator) newSchemaFromPolymorphicType(t reflect.Type, pt *polymorphicType) *SchemaOrRef {
+	name := g.componentName(t)
+	key := schemaKey{t: t, tag: g.mediaTag()}
+
+	if n, ok := g.schemaTypes[key]; ok {
+		return &SchemaOrRef{Reference: &Reference{
+			Ref: componentsSchemaPath + n,
+		}}
+	}
+	if sor, ok := g.inliningSchema(key); ok {
+		return sor
+	}
+	registered := false
+	if name != "" {
+		name, registered = g.registerSchemaName(name, t)
+		if !registered {
+			g.inlining[key] = struct{}{}
+			defer delete(g.inlining, key)
+		} else {
+			g.schemaTypes[key] = name
+		}
+	}
+	keys := make([]string, 0, len(pt.mapping))
+	for k := range pt.mapping {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var (
+		schemas []*SchemaOrRef
+		seen    = make(map[reflect.Type]struct{})
+		mapping = make(map[string]string)
+		values  = make(map[reflect.Type][]interface{})
+	)
+	// Collect the discriminator values
+	// of each implementation type.
+	for _, k := range keys {
+		it := pt.mapping[k]
+		if it.Kind() == reflect.Ptr {
+			it = it.Elem()
+		}
+		values[it] = append(values[it], k)
+	}
+	for _, k := range keys {
+		it := pt.mapping[k]
+		sor := g.newSchemaFromType(it)
+		if sor == nil {
+			continue
+		}
+		if sor.Reference != nil {
+			mapping[k] = sor.Reference.Ref
+		} else if pt.propertyName != "" {
+			g.error(&TypeError{
+				Message: "implementation of discriminated type must be a named type",
+				Type:    it,
+			})
+			continue
+		}
+		if it.Kind() == reflect.Ptr {
+			it = it.Elem()
+		}
+		// The same type may be mapped by several
+		// discriminator values.
+		if _, ok := seen[it]; ok {
+			continue
+		}
+		seen[it] = struct{}{}
+		schemas = append(schemas, sor)
+
+		if pt.propertyName != "" {
+			g.constrainDiscriminator(sor, pt.propertyName, values[it])
+		}
+	}
+	schema := &Schema{}
+
+	switch pt.kind {
+	case "oneOf":
+		schema.OneOf = schemas
+	case "anyOf":
+		schema.AnyOf = schemas
+	}
+	if pt.propertyName != "" {
+		schema.Discriminator = &Discriminator{
+			PropertyName: pt.propertyName,
+			Mapping:      mapping,
+		}
+	}
+	sor := &SchemaOrRef{Schema: schema}
+
+	if registered {
+		g.api.Components.Schemas[name] = sor
+
+		return &SchemaOrRef{Reference: &Reference{
+			Ref: componentsSchemaPath + name,
+		}}
+	}
+	return sor
+}
+
+// constrainDiscriminator marks the discriminator property
+// of the implementation schema sor as required, and restricts
+// its values to those that identify the implementation.
+func (g *Generator) constrainDiscriminator(sor *SchemaOrRef, property string, values []interface{}) {
+	schema := g.api.ResolveSchema(sor)
+	if schema == nil {
+		return
+	}
+	if !containsString(schema.Required, property) {
+		schema.Required = append(schema.Required, property)
+	}
+	if schema.Properties == nil {
+		schema.Properties = make(map[string]*SchemaOrRef)
+	}
+	ps := schema.Properties[property]
+
+	switch {
+	case ps == nil:
+		ps = &SchemaOrRef{Schema: &Schema{Type: "string"}}
+	case ps.Schema != nil:
+		// Copy the schema, which may
+		// be shared with other fields.
+		cp := *ps.Schema
+		ps = &SchemaOrRef{Schema: &cp}
+	default:
+		// A reference cannot have siblings.
+		ps = &SchemaOrRef{Schema: &Schema{AllOf: []*SchemaOrRef{ps}}}
+	}
+	ps.Enum = values
+	schema.Properties[property] = ps
+}
+
 // flattenStructSchema recursively flatten the embedded
 // fields of the struct type t to the given schema.
 func (g *Generator) flattenStructSchema(t, parent reflect.Type, schema *Schema) *Schema {
@@ -1015,7 +1869,7 @@ func (g *Generator) flattenStructSchema(t, parent reflect.Type, schema *Schema)
 			ft = ft.Elem()
 		}
 		isUnexported := f.PkgPath != ""
-		mediaTag := mediaTags[tonic.MediaType()]
+		mediaTag := g.mediaTag()
 		_, hasTag := f.Tag.Lookup(mediaTag)
 
 		if f.Anonymous && !hasTag {
@@ -1115,17 +1969,13 @@ func (g *Generator) resolveSchema(s *SchemaOrRef) *Schema {
 // resolveParameter returns either the inlined parameter
 // in p or the one referenced in the API components.
 func (g *Generator) resolveParameter(p *ParameterOrRef) *Parameter {
-	// Parameters are always automatically inlined in the spec.
-	if p.Parameter != nil && p.Reference == nil {
-		return p.Parameter
-	}
-	return nil
+	return g.api.ResolveParameter(p)
 }
 
 // typeName returns the unique name of a type, which is
-// the concatenation of the package name and the name
-// of the given type, transformed to CamelCase without
-// a dot separator between the two parts.
+// by default the concatenation of the package name and
+// the name of the given type, transformed to CamelCase
+// without a dot separator between the two parts.
 func (g *Generator) typeName(t reflect.Type) string {
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
@@ -1149,21 +1999,49 @@ func (g *Generator) typeName(t reflect.Type) string {
 			return tn.TypeName()
 		}
 	}
-	name := t.String() // package.name.
-	sp := strings.Index(name, ".")
-	pkg := name[:sp]
+	if g.namer != nil {
+		return g.namer.SchemaName(t)
+	}
+	return schemaName(t, g.naming, g.genericNamer)
+}
+
+// mediaTag returns the name of the serialization tag of
+// the media type of the schemas being generated, which
+// default to the tag of the media type of tonic.
+func (g *Generator) mediaTag() string {
+	return g.mediaTypeTag(g.mediaType)
+}
+
+// mediaTypeTag returns the name of the serialization
+// tag of the media type mt, which default to the tag
+// of the media type of tonic.
+func (g *Generator) mediaTypeTag(mt string) string {
+	if tag, ok := g.mediaTags[mt]; ok {
+		return tag
+	}
+	return g.mediaTags[tonic.MediaType()]
+}
 
-	// If the package is the main package, remove
-	// the package part from the name.
-	if pkg == "main" {
-		pkg = ""
+// tagSuffix returns the suffix of the names of the
+// schemas generated for the media type mt, which is
+// empty if its serialization tag is the default one.
+func (g *Generator) tagSuffix(mt string) string {
+	if tag := g.mediaTypeTag(mt); tag != g.mediaTags[tonic.MediaType()] {
+		return strings.ToUpper(tag)
 	}
-	typ := name[sp+1:]
+	return ""
+}
 
-	if !g.fullNames {
-		return strings.Title(typ)
+// componentName returns the name of the component schema
+// of the type t. The names of the schemas generated with a
+// non-default serialization tag are suffixed with the tag,
+// such as FruitXML.
+func (g *Generator) componentName(t reflect.Type) string {
+	name := g.typeName(t)
+	if name != "" {
+		name += g.tagSuffix(g.mediaType)
 	}
-	return strings.Title(pkg) + strings.Title(typ)
+	return name
 }
 
 // updateSchemaValidation fills the fields of the schema
@@ -1179,60 +2057,69 @@ func (g *Generator) updateSchemaValidation(schema *Schema, sf reflect.StructFiel
 	if sf.Type.Kind() == reflect.Ptr {
 		ft = sf.Type.Elem()
 	}
-	tags := strings.Split(ts, ",")
-
-	for _, t := range tags {
+	for _, t := range strings.Split(ts, ",") {
+		// The following tags apply to the
+		// items or the keys of the field.
 		if t == "dive" || t == "keys" {
 			break
 		}
-		if t == "email" {
-			schema.Format = "email"
-			break
-		}
-		// Tags can be joined together with an OR operator.
+		// Tags can be joined together with an OR operator,
+		// in which case each alternative is translated to
+		// one of the schemas of an anyOf.
 		parts := strings.Split(t, "|")
-
+		if len(parts) == 1 {
+			g.applyValidator(schema, t, ft)
+			continue
+		}
+		var alts []*SchemaOrRef
 		for _, p := range parts {
-			var k, v string
-			// Split k/v pair using separator.
-			sepIdx := strings.Index(p, "=")
-			if sepIdx == -1 {
-				k = p
-			} else {
-				k = p[:sepIdx]
-				v = p[sepIdx+1:]
-			}
-			// Handle validators with value.
-			switch k {
-			case "len", "max", "min", "eq", "gt", "gte", "lt", "lte":
-				n, err := strconv.Atoi(v)
-				if err != nil {
-					continue
-				}
-				switch k {
-				case "len":
-					setSchemaLen(schema, n, ft)
-				case "max", "lte":
-					setSchemaMax(schema, n, ft)
-				case "min", "gte":
-					setSchemaMin(schema, n, ft)
-				case "lt":
-					setSchemaMax(schema, n-1, ft)
-				case "gt":
-					setSchemaMin(schema, n+1, ft)
-				case "eq":
-					setSchemaEq(schema, n, ft)
-				}
+			alt := &Schema{}
+			if !g.applyValidator(alt, p, ft) || reflect.DeepEqual(alt, &Schema{}) {
+				// An alternative that doesn't constrain
+				// the schema makes the others useless.
+				alts = nil
+				break
 			}
+			alts = append(alts, &SchemaOrRef{Schema: alt})
 		}
+		schema.AnyOf = append(schema.AnyOf, alts...)
 	}
 	return schema
 }
 
+// applyValidator updates the schema of a field of type
+// t with the translation of the validator tag, and returns
+// whether the tag is known.
+func (g *Generator) applyValidator(schema *Schema, tag string, t reflect.Type) bool {
+	var k, v string
+	// Split k/v pair using separator.
+	if i := strings.Index(tag, "="); i == -1 {
+		k = tag
+	} else {
+		k, v = tag[:i], tag[i+1:]
+	}
+	fn, ok := g.validators[k]
+	if fn != nil {
+		fn(schema, v, t)
+	}
+	return ok
+}
+
 func (g *Generator) error(err error) {
 	g.errors = append(g.errors, err)
 }
 
+// containsString returns whether the string s
+// is in the list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // fieldTagName returns the name of a struct field
 // extracted from a serialization tag using its name.
 func fieldNameFromTag(sf reflect.StructField, tagName string) string {
@@ -1255,6 +2142,66 @@ func fieldNameFromTag(sf reflect.StructField, tagName string) string {
 	return name
 }
 
+// parseExtensions parses the vendor extensions of the
+// extensions tag, separated by commas, such as x-order=1.
+// The values are decoded as JSON if possible, otherwise
+// used as strings, and an extension without value is true.
+func parseExtensions(tag string) (map[string]interface{}, error) {
+	ext := make(map[string]interface{})
+
+	for _, kv := range splitExtensionsTag(tag) {
+		if kv == "" {
+			continue
+		}
+		k, v := kv, ""
+		if i := strings.IndexByte(kv, '='); i != -1 {
+			k, v = kv[:i], kv[i+1:]
+		}
+		if err := checkExtension(&Schema{}, k); err != nil {
+			return nil, err
+		}
+		if v == "" {
+			ext[k] = true
+			continue
+		}
+		var val interface{}
+		if err := json.Unmarshal([]byte(v), &val); err != nil {
+			val = v
+		}
+		ext[k] = val
+	}
+	return ext, nil
+}
+
+// splitExtensionsTag splits the extensions tag on
+// the commas that are not part of a JSON value, such
+// as the elements of an array or a quoted string.
+func splitExtensionsTag(tag string) []string {
+	var (
+		exts   []string
+		depth  int
+		start  int
+		quoted bool
+	)
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case quoted && c == '\\':
+			i++ // skip the escaped character
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			exts = append(exts, strings.TrimSpace(tag[start:i]))
+			start = i + 1
+		}
+	}
+	return append(exts, strings.TrimSpace(tag[start:]))
+}
+
 /// parseExampleValue is used to transform the string representation of the example value to the correct type.
 func parseExampleValue(t reflect.Type, value string) (interface{}, error) {
 	// If the type implements Exampler use the ParseExample method to create the example
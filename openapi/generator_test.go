@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"mime/multipart"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Pallinder/go-randomdata"
 	"github.com/loopfz/gadgeto/tonic"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 var genConfig = &SpecGenConfig{
@@ -20,8 +23,10 @@ var genConfig = &SpecGenConfig{
 	PathLocationTag:   tonic.PathTag,
 	QueryLocationTag:  tonic.QueryTag,
 	HeaderLocationTag: tonic.HeaderTag,
+	CookieLocationTag: "cookie",
 	EnumTag:           tonic.EnumTag,
 	DefaultTag:        tonic.DefaultTag,
+	FormTag:           "form",
 }
 
 var rt = reflect.TypeOf
@@ -247,6 +252,127 @@ func TestSchemaFromComplex(t *testing.T) {
 	}
 }
 
+type (
+	Event interface {
+		EventType() string
+	}
+	Created struct {
+		Type string `json:"type"`
+		ID   int    `json:"id"`
+	}
+	Deleted struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+)
+
+func (Created) EventType() string  { return "created" }
+func (*Deleted) EventType() string { return "deleted" }
+
+// TestSchemaFromPolymorphicType tests that a schema
+// can be created for an interface type which have
+// registered implementations.
+func TestSchemaFromPolymorphicType(t *testing.T) {
+	g := gen(t)
+
+	tofEvent := reflect.TypeOf((*Event)(nil)).Elem()
+
+	// Invalid registrations.
+	err := g.RegisterOneOf(rt(Created{}), "type", map[string]interface{}{"created": Created{}})
+	assert.NotNil(t, err)
+	err = g.RegisterOneOf(tofEvent, "type", nil)
+	assert.NotNil(t, err)
+	err = g.RegisterOneOf(tofEvent, "type", map[string]interface{}{"x": X{}})
+	assert.NotNil(t, err)
+
+	err = g.RegisterOneOf(tofEvent, "type", map[string]interface{}{
+		"created": Created{},
+		"deleted": &Deleted{},
+	})
+	assert.Nil(t, err)
+
+	// Type already registered.
+	err = g.RegisterAnyOf(tofEvent, "", map[string]interface{}{"created": Created{}})
+	assert.NotNil(t, err)
+
+	type T struct {
+		A Event   `json:"a"`
+		B []Event `json:"b"`
+	}
+	sor := g.newSchemaFromType(rt(T{}))
+	assert.NotNil(t, sor)
+	assert.Len(t, g.Errors(), 0)
+
+	b, err := json.Marshal(g.API().Components.Schemas["T"])
+	if err != nil {
+		t.Error(err)
+	}
+	m, err := diffJSON(b, []byte(`{
+		"type": "object",
+		"properties": {
+			"a": {"$ref": "#/components/schemas/Event"},
+			"b": {"type": "array", "items": {"$ref": "#/components/schemas/Event"}}
+		}
+	}`))
+	if err != nil {
+		t.Error(err)
+	}
+	assert.True(t, m)
+
+	b, err = json.Marshal(g.API().Components.Schemas["Event"])
+	if err != nil {
+		t.Error(err)
+	}
+	m, err = diffJSON(b, []byte(`{
+		"oneOf": [
+			{"$ref": "#/components/schemas/Created"},
+			{"$ref": "#/components/schemas/Deleted"}
+		],
+		"discriminator": {
+			"propertyName": "type",
+			"mapping": {
+				"created": "#/components/schemas/Created",
+				"deleted": "#/components/schemas/Deleted"
+			}
+		}
+	}`))
+	if err != nil {
+		t.Error(err)
+	}
+	assert.True(t, m)
+
+	// The discriminator property of the implementations
+	// is required, and restricted to their mapping value.
+	for name, want := range map[string]string{
+		"Created": `{
+			"type": "object",
+			"properties": {
+				"type": {"type": "string", "enum": ["created"]},
+				"id": {"type": "integer", "format": "int32"}
+			},
+			"required": ["type"]
+		}`,
+		"Deleted": `{
+			"type": "object",
+			"properties": {
+				"type": {"type": "string", "enum": ["deleted"]},
+				"reason": {"type": "string"}
+			},
+			"required": ["type"]
+		}`,
+	} {
+		b, err = json.Marshal(g.API().Components.Schemas[name])
+		if err != nil {
+			t.Error(err)
+		}
+		m, err = diffJSON(b, []byte(want))
+		if err != nil {
+			t.Error(err)
+		}
+		assert.True(t, m, name)
+	}
+}
+
 // TestNewSchemaFromStructErrors tests the errors
 // case of generation of a schema from a struct.
 func TestNewSchemaFromStructErrors(t *testing.T) {
@@ -392,6 +518,73 @@ func TestNewSchemaFromStructFieldFormat(t *testing.T) {
 	assert.Equal(t, sor.Schema.Format, "email")
 }
 
+// TestValidatorTags tests that the tags of the validator
+// package are translated to the keywords of the schemas,
+// and that custom validators can be registered.
+func TestValidatorTags(t *testing.T) {
+	g := gen(t)
+
+	err := g.RegisterValidator("iso3166_1_alpha2", func(schema *Schema, _ string, _ reflect.Type) {
+		schema.Pattern = "^[A-Z]{2}$"
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, g.RegisterValidator("", nil))
+
+	type T struct {
+		A string    `validate:"oneof=red green 'light blue'"`
+		B int       `validate:"oneof=1 2 3"`
+		C string    `validate:"uuid4"`
+		D string    `validate:"url"`
+		E string    `validate:"datetime=2006-01-02"`
+		F string    `validate:"alpha,startswith=a.b"`
+		G []string  `validate:"unique,max=5,dive,max=10"`
+		H string    `validate:"email,max=10"`
+		I string    `validate:"ipv4|ipv6"`
+		J string    `validate:"ipv4|required"`
+		K string    `validate:"omitempty,iso3166_1_alpha2"`
+		L int       `validate:"gt=1,lt=10"`
+		M string    `validate:"unknown,min=2"`
+		N *string   `validate:"contains=@"`
+		O time.Time `validate:"required"`
+	}
+	typ := reflect.TypeOf(T{})
+
+	expected := []*Schema{
+		{Type: "string", Enum: []interface{}{"red", "green", "light blue"}},
+		{Type: "integer", Format: "int32", Enum: []interface{}{int64(1), int64(2), int64(3)}},
+		{Type: "string", Format: "uuid"},
+		{Type: "string", Format: "uri"},
+		{Type: "string", Format: "date"},
+		{Type: "string", Pattern: "^[a-zA-Z]+$", AllOf: []*SchemaOrRef{
+			{Schema: &Schema{Pattern: `^a\.b`}},
+		}},
+		{Type: "array", Items: &SchemaOrRef{Schema: &Schema{Type: "string"}}, UniqueItems: true, MaxItems: 5},
+		{Type: "string", Format: "email", MaxLength: 10},
+		{Type: "string", AnyOf: []*SchemaOrRef{
+			{Schema: &Schema{Format: "ipv4"}},
+			{Schema: &Schema{Format: "ipv6"}},
+		}},
+		{Type: "string"},
+		{Type: "string", Pattern: "^[A-Z]{2}$"},
+		{Type: "integer", Format: "int32", Minimum: float64Ptr(2), Maximum: float64Ptr(9)},
+		{Type: "string", MinLength: 2},
+		{Type: "string", Pattern: "@", Nullable: true},
+		{Type: "string", Format: "date-time"},
+	}
+	for i, want := range expected {
+		sf := typ.Field(i)
+		sor := g.newSchemaFromStructField(sf, false, sf.Name, typ)
+		if assert.NotNil(t, sor, sf.Name) {
+			assert.Equal(t, want, sor.Schema, sf.Name)
+		}
+	}
+	assert.Empty(t, g.Errors())
+
+	// The registrations are local to the generator.
+	sor := gen(t).newSchemaFromStructField(typ.Field(10), false, "K", typ)
+	assert.Empty(t, sor.Schema.Pattern)
+}
+
 func TestNewSchemaFromEnumField(t *testing.T) {
 	g := gen(t)
 
@@ -603,6 +796,202 @@ func TestTypeName(t *testing.T) {
 	assert.Equal(t, "", g.typeName(rt(struct{}{})))
 }
 
+// TestSchemaNamer tests the built-in naming strategies,
+// the custom namers and the detection of the collisions.
+func TestSchemaNamer(t *testing.T) {
+	assert.Equal(t, "Y", NewSchemaNamer(ShortNames, nil).SchemaName(rt(new(Y))))
+	assert.Equal(t, "OpenapiY", NewSchemaNamer(PackageNames, nil).SchemaName(rt(Y{})))
+	assert.Regexp(t, `^Y_[0-9a-f]{8}$`, NewSchemaNamer(PathHashNames, nil).SchemaName(rt(Y{})))
+	assert.Equal(t, "", NewSchemaNamer(PackageNames, nil).SchemaName(rt(struct{}{})))
+
+	g := gen(t)
+
+	g.SetNamingStrategy(PathHashNames)
+	assert.Equal(t, NewSchemaNamer(PathHashNames, nil).SchemaName(rt(Y{})), g.typeName(rt(Y{})))
+
+	g.SetSchemaNamer(SchemaNamerFunc(func(t reflect.Type) string {
+		return strings.ToLower(NewSchemaNamer(PackageNames, nil).SchemaName(t))
+	}))
+	assert.Equal(t, "openapiy", g.typeName(rt(Y{})))
+	assert.Equal(t, "", g.typeName(rt(struct{}{})))
+
+	// The Typer interface has precedence.
+	assert.Equal(t, "XXX", g.typeName(rt(X{})))
+
+	g.SetSchemaNamer(nil)
+	g.UseFullSchemaNames(false)
+	assert.Equal(t, "Y", g.typeName(rt(Y{})))
+
+	// A type with the same name as another
+	// type is reported and inlined.
+	outer := rt(Y{})
+	sor := g.newSchemaFromType(outer)
+	assert.NotNil(t, sor.Reference)
+
+	type Y struct {
+		A string `json:"a"`
+	}
+	sor = g.newSchemaFromType(rt(Y{}))
+	assert.Nil(t, sor.Reference)
+	assert.Contains(t, sor.Schema.Properties, "a")
+
+	// The same type is not a collision.
+	sor = g.newSchemaFromType(outer)
+	assert.NotNil(t, sor.Reference)
+
+	if assert.Len(t, g.Errors(), 1) {
+		assert.Contains(t, g.Errors()[0].Error(), "schema name Y is already used")
+	}
+}
+
+// TestNameCollisions tests that the collisions of the
+// component schema names are reported as errors, or
+// disambiguated with a numeric suffix.
+func TestNameCollisions(t *testing.T) {
+	type TestInput struct {
+		A string `json:"a"`
+	}
+	type In struct {
+		B string `json:"b"`
+	}
+	for _, disambiguate := range []bool{false, true} {
+		g := gen(t)
+		g.SetDisambiguateNames(disambiguate)
+
+		outer := rt(Y{})
+		g.newSchemaFromType(outer)
+		g.newSchemaFromType(rt(TestInput{}))
+
+		type Y struct {
+			A string `json:"a"`
+		}
+		sor := g.newSchemaFromType(rt(Y{}))
+		op, err := g.AddOperation("/test", "POST", "", rt(&In{}), nil, &OperationInfo{ID: "Test", StatusCode: 204})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := op.RequestBody.Content["application/json"].Schema
+
+		if disambiguate {
+			assert.Empty(t, g.Errors())
+			assert.Equal(t, componentsSchemaPath+"Y2", sor.Ref)
+			assert.Equal(t, componentsSchemaPath+"TestInput2", body.Ref)
+
+			// The names of the types are stable.
+			assert.Equal(t, sor, g.newSchemaFromType(rt(Y{})))
+			assert.Equal(t, componentsSchemaPath+"Y", g.newSchemaFromType(outer).Ref)
+			continue
+		}
+		assert.Nil(t, sor.Reference)
+		assert.Nil(t, body.Reference)
+		assert.Contains(t, body.Schema.Properties, "b")
+
+		if assert.Len(t, g.Errors(), 2) {
+			nce, ok := g.Errors()[0].(*NameCollisionError)
+			if assert.True(t, ok) {
+				assert.Equal(t, "Y", nce.Name)
+				assert.Equal(t, rt(Y{}), nce.Type)
+				assert.Equal(t, outer, nce.Owner)
+			}
+			nce, ok = g.Errors()[1].(*NameCollisionError)
+			if assert.True(t, ok) {
+				assert.Equal(t, "TestInput", nce.Name)
+				assert.Equal(t, rt(In{}), nce.Type)
+			}
+		}
+	}
+}
+
+// TestNameCollisionsRecursive tests that a type whose name
+// is owned by another type is inlined each time it is used,
+// and never referenced with the name of the owner.
+func TestNameCollisionsRecursive(t *testing.T) {
+	g := gen(t)
+	g.newSchemaFromType(rt(Y{}))
+
+	type Y struct {
+		B    string `json:"b"`
+		Next *Y     `json:"next"`
+	}
+	type Z struct {
+		X1 *Y `json:"x1"`
+		X2 *Y `json:"x2"`
+	}
+	sor := g.newSchemaFromType(rt(Z{}))
+	z := g.resolveSchema(sor)
+	if !assert.NotNil(t, z) {
+		return
+	}
+	for _, name := range []string{"x1", "x2"} {
+		y := z.Properties[name]
+		if assert.NotNil(t, y.Schema, name) {
+			assert.Contains(t, y.Schema.Properties, "b", name)
+
+			// The recursive reference accepts any value.
+			next := y.Schema.Properties["next"]
+			assert.Nil(t, next.Reference, name)
+			assert.Equal(t, &Schema{}, next.Schema, name)
+		}
+	}
+	assert.Len(t, g.Errors(), 1)
+}
+
+// TestExampleValidation tests that the examples and the
+// default values that do not satisfy the constraints of
+// their schema are reported.
+func TestExampleValidation(t *testing.T) {
+	type Out struct {
+		Name  string `json:"name" validate:"required"`
+		Color string `json:"color" enum:"red,green" example:"blue"`
+		Code  string `json:"code" validate:"max=3" example:"ABCD"`
+		Valid string `json:"valid" validate:"max=3" enum:"abc" example:"abc"`
+	}
+	type In struct {
+		Limit int    `query:"limit" validate:"max=100" default:"500"`
+		Page  int    `query:"page" validate:"min=1" default:"1"`
+		Sort  string `query:"sort" enum:"asc,desc" default:"up"`
+	}
+	g := gen(t)
+
+	_, err := g.AddOperation("/test", "GET", "", rt(&In{}), rt(&Out{}), &OperationInfo{
+		ID:         "Test",
+		StatusCode: 200,
+		Responses: []*OperationResponse{
+			{
+				Code:    "400",
+				Model:   &Out{},
+				Example: map[string]interface{}{"color": "red"},
+			},
+			{
+				Code:  "409",
+				Model: &Out{},
+				Examples: map[string]interface{}{
+					"valid":   &Out{Name: "John", Color: "green", Code: "ABC", Valid: "abc"},
+					"invalid": &Out{Name: "John", Color: "green", Code: "ABCDE", Valid: "abc"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []string
+	for _, err := range g.Errors() {
+		fe, ok := err.(*FieldError)
+		if assert.True(t, ok) {
+			msgs = append(msgs, fe.Name+": "+fe.Message)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"limit: default value does not match the field schema: value must be lower than or equal to 100",
+		"sort: default value does not match the field schema: value must be one of [asc desc]",
+		"color: example value does not match the field schema: value must be one of [red green]",
+		"code: example value does not match the field schema: length must be lower than or equal to 3",
+		"example: response example does not match its schema: property is required at /name",
+		"invalid: response example does not match its schema: length must be lower than or equal to 3 at /code",
+	}, msgs)
+}
+
 // TestSetInfo tests that the informations
 // of the spec can be modified.
 func TestSetInfo(t *testing.T) {
@@ -617,6 +1006,433 @@ func TestSetInfo(t *testing.T) {
 	assert.Equal(t, infos, g.API().Info)
 }
 
+// TestCookieParameters tests that the fields with
+// a cookie tag are added as cookie parameters.
+func TestCookieParameters(t *testing.T) {
+	type In struct {
+		Session string  `cookie:"session" validate:"required"`
+		Trace   string  `header:"X-Trace"`
+		CSRF    *string `cookie:"csrf" description:"CSRF token"`
+	}
+	g := gen(t)
+
+	op, err := g.AddOperation("/cookies", "GET", "", reflect.TypeOf(&In{}), nil, &OperationInfo{
+		ID:         "Cookies",
+		StatusCode: 200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, op.Parameters, 3) {
+		assert.Equal(t, "X-Trace", op.Parameters[0].Name)
+		assert.Equal(t, "header", op.Parameters[0].In)
+
+		assert.Equal(t, "csrf", op.Parameters[1].Name)
+		assert.Equal(t, "cookie", op.Parameters[1].In)
+		assert.Equal(t, "CSRF token", op.Parameters[1].Description)
+		assert.False(t, op.Parameters[1].Required)
+
+		assert.Equal(t, "session", op.Parameters[2].Name)
+		assert.Equal(t, "cookie", op.Parameters[2].In)
+		assert.True(t, op.Parameters[2].Required)
+	}
+	// A field cannot be both a
+	// cookie and a header.
+	type Conflict struct {
+		A string `cookie:"a" header:"a"`
+	}
+	_, err = g.AddOperation("/conflict", "GET", "", reflect.TypeOf(&Conflict{}), nil, &OperationInfo{
+		ID:         "Conflict",
+		StatusCode: 200,
+	})
+	assert.NotNil(t, err)
+}
+
+// TestFormRequestBody tests that the form fields of
+// an input type are documented as a form request body.
+func TestFormRequestBody(t *testing.T) {
+	type Upload struct {
+		ID     string                  `path:"id"`
+		Title  string                  `form:"title" validate:"required"`
+		Avatar *multipart.FileHeader   `form:"avatar" contentType:"image/png, image/jpeg"`
+		Files  []*multipart.FileHeader `form:"files"`
+	}
+	type Login struct {
+		User     string `form:"user"`
+		Password string `form:"password" format:"password"`
+	}
+	g := gen(t)
+
+	op, err := g.AddOperation("/upload/{id}", "POST", "", reflect.TypeOf(&Upload{}), nil, &OperationInfo{
+		ID:         "Upload",
+		StatusCode: 201,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, op.Parameters, 1)
+
+	if assert.NotNil(t, op.RequestBody) && assert.Len(t, op.RequestBody.Content, 1) {
+		media := op.RequestBody.Content["multipart/form-data"]
+		if assert.NotNil(t, media) {
+			assert.Equal(t, "#/components/schemas/UploadInput", media.Schema.Ref)
+			assert.Equal(t, map[string]*Encoding{
+				"avatar": {ContentType: "image/png, image/jpeg"},
+			}, media.Encoding)
+		}
+		schema := g.API().Components.Schemas["UploadInput"].Schema
+		if assert.NotNil(t, schema) {
+			assert.Equal(t, []string{"title"}, schema.Required)
+			assert.Equal(t, &Schema{Type: "string", Format: "binary"}, schema.Properties["avatar"].Schema)
+			assert.Equal(t, "binary", schema.Properties["files"].Items.Format)
+		}
+	}
+	// Without files, the form tag is only considered
+	// if the operation consumes a form, and the body
+	// is url-encoded.
+	for _, consumes := range [][]string{{"application/x-www-form-urlencoded"}, {"multipart/form-data"}} {
+		op, err = g.AddOperation("/login", "POST", "", reflect.TypeOf(&Login{}), nil, &OperationInfo{
+			ID:         "Login" + strings.Title(strings.Split(consumes[0], "/")[0]),
+			StatusCode: 200,
+			Consumes:   consumes,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.NotNil(t, op.RequestBody) && assert.Len(t, op.RequestBody.Content, 1) {
+			assert.NotNil(t, op.RequestBody.Content[consumes[0]])
+		}
+	}
+	// Otherwise, the form tag is used with the json
+	// tag for the binding of gin, and the body is JSON.
+	type Create struct {
+		Name string `json:"name" form:"name"`
+	}
+	op, err = g.AddOperation("/create", "POST", "", reflect.TypeOf(&Create{}), nil, &OperationInfo{
+		ID:         "Create",
+		StatusCode: 201,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, op.RequestBody) && assert.Len(t, op.RequestBody.Content, 1) {
+		assert.NotNil(t, op.RequestBody.Content["application/json"])
+	}
+}
+
+// TestResponseMediaTypes tests that a schema is generated
+// for each media type of the responses of an operation.
+func TestResponseMediaTypes(t *testing.T) {
+	type Fruit struct {
+		Name  string `json:"name" xml:"Name" csv:"fruit_name"`
+		Price int    `json:"price" xml:"price,attr" csv:"fruit_price"`
+	}
+	type Error struct {
+		Message string `json:"message" xml:"msg"`
+	}
+	g := gen(t)
+	g.SetMediaTypeTag("text/csv", "csv")
+
+	op, err := g.AddOperation("/fruits", "GET", "", nil, reflect.TypeOf([]*Fruit{}), &OperationInfo{
+		ID:                 "ListFruits",
+		StatusCode:         200,
+		Produces:           []string{"application/json", "application/xml", "text/csv"},
+		ResponseMediaTypes: map[string][]string{"404": {"application/json"}},
+		Responses: []*OperationResponse{
+			{Code: "404", Model: &Error{}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := make(map[string]string)
+	for mt, m := range op.Responses["200"].Content {
+		refs[mt] = m.Schema.Items.Ref
+	}
+	assert.Equal(t, map[string]string{
+		"application/json": "#/components/schemas/Fruit",
+		"application/xml":  "#/components/schemas/FruitXML",
+		"text/csv":         "#/components/schemas/FruitCSV",
+	}, refs)
+
+	schemas := g.API().Components.Schemas
+	for name, props := range map[string][]string{
+		"Fruit":    {"name", "price"},
+		"FruitXML": {"Name", "price"},
+		"FruitCSV": {"fruit_name", "fruit_price"},
+		"Error":    {"message"},
+	} {
+		if assert.Contains(t, schemas, name) {
+			var keys []string
+			for k := range schemas[name].Properties {
+				keys = append(keys, k)
+			}
+			assert.ElementsMatch(t, props, keys, name)
+		}
+	}
+	assert.NotContains(t, schemas, "ErrorXML")
+	assert.Len(t, op.Responses["404"].Content, 1)
+}
+
+// TestRequestMediaTypes tests that a request body
+// schema is generated for each consumed media type.
+func TestRequestMediaTypes(t *testing.T) {
+	type In struct {
+		ID    string `path:"id"`
+		Name  string `json:"name" xml:"Name" validate:"required"`
+		Color string `json:"color" xml:"colour"`
+	}
+	g := gen(t)
+
+	op, err := g.AddOperation("/fruits/{id}", "PATCH", "", reflect.TypeOf(&In{}), nil, &OperationInfo{
+		ID:         "UpdateFruit",
+		StatusCode: 200,
+		Consumes:   []string{"application/json", "application/merge-patch+json", "application/xml"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := make(map[string]string)
+	for mt, m := range op.RequestBody.Content {
+		refs[mt] = m.Schema.Ref
+	}
+	assert.Equal(t, map[string]string{
+		"application/json":             "#/components/schemas/UpdateFruitInput",
+		"application/merge-patch+json": "#/components/schemas/UpdateFruitInput",
+		"application/xml":              "#/components/schemas/UpdateFruitInputXML",
+	}, refs)
+
+	schemas := g.API().Components.Schemas
+	if assert.Contains(t, schemas, "UpdateFruitInput") {
+		assert.Equal(t, []string{"name"}, schemas["UpdateFruitInput"].Required)
+		assert.Contains(t, schemas["UpdateFruitInput"].Properties, "color")
+	}
+	if assert.Contains(t, schemas, "UpdateFruitInputXML") {
+		assert.Equal(t, []string{"Name"}, schemas["UpdateFruitInputXML"].Required)
+		assert.Contains(t, schemas["UpdateFruitInputXML"].Properties, "colour")
+	}
+}
+
+// TestComponents tests that the parameters, responses
+// and headers registered in the components can be
+// referenced by the operations.
+func TestComponents(t *testing.T) {
+	type Error struct {
+		Message string `json:"message"`
+	}
+	type In struct {
+		Limit  int    `query:"limit"`
+		Filter string `query:"filter"`
+	}
+	g := gen(t)
+
+	// The parameter of the caller can be reused.
+	offset := &Parameter{Name: "offset", In: "query"}
+	assert.Nil(t, g.RegisterParameter("offset", offset, int64(0)))
+	assert.Nil(t, offset.Schema)
+	assert.Nil(t, g.RegisterParameter("requestID", &Parameter{Name: "X-Request-ID", In: "header"}, nil))
+	assert.Nil(t, g.RegisterParameter("limit", &Parameter{Name: "limit", In: "query"}, 0))
+	assert.NotNil(t, g.RegisterParameter("offset", &Parameter{Name: "offset", In: "query"}, nil))
+	assert.NotNil(t, g.RegisterParameter("noname", &Parameter{In: "query"}, nil))
+
+	assert.Nil(t, g.RegisterHeader("rateLimit", &ResponseHeader{Description: "Remaining requests", Model: 0}))
+	assert.NotNil(t, g.RegisterHeader("rateLimit", &ResponseHeader{}))
+
+	assert.Nil(t, g.RegisterResponse("unauthorized", &OperationResponse{
+		Code:    "401",
+		Model:   &Error{},
+		Headers: []*ResponseHeader{{Name: "X-Rate-Limit", Ref: "rateLimit"}},
+	}))
+	assert.NotNil(t, g.RegisterResponse("unauthorized", &OperationResponse{}))
+
+	api := g.API()
+	assert.Equal(t, "Unauthorized", api.Components.Responses["unauthorized"].Description)
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, api.Components.Parameters["offset"].Schema.Schema)
+	assert.Equal(t, &Schema{Type: "string"}, api.Components.Parameters["requestID"].Schema.Schema)
+
+	op, err := g.AddOperation("/items", "GET", "", reflect.TypeOf(&In{}), nil, &OperationInfo{
+		ID:            "ListItems",
+		StatusCode:    200,
+		ParameterRefs: []string{"requestID", "offset"},
+		Headers:       []*ResponseHeader{{Name: "X-Rate-Limit", Ref: "rateLimit"}},
+		Responses: []*OperationResponse{
+			{Code: "401", Ref: "unauthorized"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The parameters are sorted by location
+	// and name, including the references.
+	var names []string
+	for _, p := range op.Parameters {
+		names = append(names, api.ResolveParameter(p).Name)
+	}
+	assert.Equal(t, []string{"filter", "limit", "offset", "X-Request-ID"}, names)
+	assert.Equal(t, "#/components/parameters/requestID", op.Parameters[3].Ref)
+	assert.Equal(t, "#/components/responses/unauthorized", op.Responses["401"].Ref)
+	assert.Equal(t, "#/components/headers/rateLimit", op.Responses["200"].Headers["X-Rate-Limit"].Ref)
+
+	// A reference to a parameter that is
+	// also declared by the input is rejected.
+	_, err = g.AddOperation("/items", "POST", "", reflect.TypeOf(&In{}), nil, &OperationInfo{
+		ID:            "CreateItem",
+		StatusCode:    201,
+		ParameterRefs: []string{"limit"},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, g.Errors(), 1)
+
+	// The references are sorted without input type.
+	op, err = g.AddOperation("/status", "GET", "", nil, nil, &OperationInfo{
+		ID:            "Status",
+		StatusCode:    200,
+		ParameterRefs: []string{"requestID", "offset", "limit"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = nil
+	for _, p := range op.Parameters {
+		names = append(names, api.ResolveParameter(p).Name)
+	}
+	assert.Equal(t, []string{"limit", "offset", "X-Request-ID"}, names)
+
+	// Unknown references.
+	for i, info := range []*OperationInfo{
+		{ParameterRefs: []string{"unknown"}},
+		{Responses: []*OperationResponse{{Code: "404", Ref: "unknown"}}},
+		{Headers: []*ResponseHeader{{Name: "X-Unknown", Ref: "unknown"}}},
+	} {
+		info.ID = fmt.Sprintf("Unknown%d", i)
+		info.StatusCode = 200
+		_, err = g.AddOperation("/unknown", "GET", "", nil, nil, info)
+		assert.NotNil(t, err)
+	}
+}
+
+// TestSetVersion tests that the version of the
+// specification can be changed.
+func TestSetVersion(t *testing.T) {
+	g := gen(t)
+	assert.Equal(t, Version30, g.API().OpenAPI)
+
+	err := g.SetVersion("2.0")
+	assert.NotNil(t, err)
+
+	err = g.SetVersion(Version31)
+	assert.Nil(t, err)
+	assert.Equal(t, Version31, g.API().OpenAPI)
+	assert.NotEmpty(t, g.API().JSONSchemaDialect)
+}
+
+// TestAPIVersion31 tests that the schemas of the
+// specification are converted to their OpenAPI 3.1
+// representation.
+func TestAPIVersion31(t *testing.T) {
+	type T struct {
+		A *string `json:"a" enum:"foo,bar"`
+		B int     `json:"b" validate:"eq=42"`
+		C string  `json:"c" example:"baz"`
+		D *int    `json:"d"`
+	}
+	g := gen(t)
+
+	_, err := g.AddOperation("/test", "POST", "", nil, reflect.TypeOf(T{}), &OperationInfo{
+		ID:         "Test",
+		StatusCode: 200,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	// OpenAPI 3.0 representation.
+	b, err := json.Marshal(g.API().Components.Schemas["T"])
+	if err != nil {
+		t.Error(err)
+	}
+	m, err := diffJSON(b, []byte(`{
+		"type": "object",
+		"properties": {
+			"a": {"type": "string", "nullable": true, "enum": ["foo", "bar"]},
+			"b": {"type": "integer", "format": "int32"},
+			"c": {"type": "string", "example": "baz"},
+			"d": {"type": "integer", "format": "int32", "nullable": true}
+		}
+	}`))
+	if err != nil {
+		t.Error(err)
+	}
+	assert.True(t, m)
+
+	// OpenAPI 3.1 representation.
+	err = g.SetVersion(Version31)
+	assert.Nil(t, err)
+
+	b, err = json.Marshal(g.API().Components.Schemas["T"])
+	if err != nil {
+		t.Error(err)
+	}
+	m, err = diffJSON(b, []byte(`{
+		"$schema": "https://spec.openapis.org/oas/3.1/dialect/base",
+		"type": "object",
+		"properties": {
+			"a": {"type": ["string", "null"], "enum": ["foo", "bar", null]},
+			"b": {"type": "integer", "format": "int32", "const": 42},
+			"c": {"type": "string", "examples": ["baz"]},
+			"d": {"type": ["integer", "null"], "format": "int32"}
+		}
+	}`))
+	if err != nil {
+		t.Error(err)
+	}
+	assert.True(t, m)
+
+	y, err := yaml.Marshal(g.API().Components.Schemas["T"])
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Contains(t, string(y), "examples:")
+	assert.NotContains(t, string(y), "nullable")
+
+	// The internal schemas must not be altered.
+	b, err = json.Marshal(g.api.Components.Schemas["T"])
+	if err != nil {
+		t.Error(err)
+	}
+	assert.NotContains(t, string(b), "examples")
+
+	// Exclusive bounds are numbers.
+	s31, err := json.Marshal(schemaTo31(&SchemaOrRef{Schema: &Schema{
+		Type:             "integer",
+		Maximum:          float64Ptr(10),
+		ExclusiveMaximum: true,
+	}}))
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, `{"type":"integer","exclusiveMaximum":10}`, string(s31))
+
+	// The nullable schemas without type accept null.
+	ref := &SchemaOrRef{Reference: &Reference{Ref: "#/components/schemas/T"}}
+	str := &SchemaOrRef{Schema: &Schema{Type: "string"}}
+
+	for _, tt := range []struct {
+		schema *Schema
+		json   string
+	}{
+		{&Schema{Nullable: true}, `{}`},
+		{&Schema{OneOf: []*SchemaOrRef{ref, str}, Nullable: true}, `{"oneOf":[{"$ref":"#/components/schemas/T"},{"type":"string"},{"type":"null"}]}`},
+		{&Schema{AnyOf: []*SchemaOrRef{ref}, Nullable: true}, `{"anyOf":[{"$ref":"#/components/schemas/T"},{"type":"null"}]}`},
+		{&Schema{AllOf: []*SchemaOrRef{ref}, Nullable: true}, `{"anyOf":[{"allOf":[{"$ref":"#/components/schemas/T"}]},{"type":"null"}]}`},
+	} {
+		b, err := json.Marshal(schemaTo31(&SchemaOrRef{Schema: tt.schema}))
+		if err != nil {
+			t.Error(err)
+		}
+		assert.JSONEq(t, tt.json, string(b))
+	}
+}
+
 // TestSetOperationByMethod tests that an operation
 // is added to a path item accordingly to the given
 // HTTP method.
@@ -649,29 +1465,29 @@ func TestSetOperationResponseError(t *testing.T) {
 	op := &Operation{
 		Responses: make(Responses),
 	}
-	err := g.setOperationResponse(op, reflect.TypeOf(new(string)), "200", "application/json", "", nil, nil, nil)
+	err := g.setOperationResponse(op, reflect.TypeOf(new(string)), "200", []string{"application/json"}, "", nil, nil, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, "OK", op.Responses["200"].Description)
 
-	err = g.setOperationResponse(op, reflect.TypeOf(new(string)), "429", "application/json", "testDesc", nil, nil, nil)
+	err = g.setOperationResponse(op, reflect.TypeOf(new(string)), "429", []string{"application/json"}, "testDesc", nil, nil, nil)
 	assert.Nil(t, err)
 	assert.Equal(t, "testDesc", op.Responses["429"].Description)
 
 	// Add another response with same code.
-	err = g.setOperationResponse(op, reflect.TypeOf(new(int)), "200", "application/xml", "", nil, nil, nil)
+	err = g.setOperationResponse(op, reflect.TypeOf(new(int)), "200", []string{"application/xml"}, "", nil, nil, nil)
 	assert.NotNil(t, err)
 
 	// Add invalid response code that cannot
 	// be converted to an integer.
-	err = g.setOperationResponse(op, reflect.TypeOf(new(bool)), "two-hundred", "", "", nil, nil, nil)
+	err = g.setOperationResponse(op, reflect.TypeOf(new(bool)), "two-hundred", []string{""}, "", nil, nil, nil)
 	assert.NotNil(t, err)
 
 	// Add out of range response code.
-	err = g.setOperationResponse(op, reflect.TypeOf(new(bool)), "777", "", "", nil, nil, nil)
+	err = g.setOperationResponse(op, reflect.TypeOf(new(bool)), "777", []string{""}, "", nil, nil, nil)
 	assert.NotNil(t, err)
 
 	// Cannot set both example and examples
-	err = g.setOperationResponse(op, reflect.TypeOf(new(bool)), "404", "", "", nil, "notFoundExample", map[string]interface{}{"badRequest": "message"})
+	err = g.setOperationResponse(op, reflect.TypeOf(new(bool)), "404", []string{""}, "", nil, "notFoundExample", map[string]interface{}{"badRequest": "message"})
 	assert.NotNil(t, err)
 }
 
@@ -685,7 +1501,7 @@ func TestSetOperationResponseExample(t *testing.T) {
 
 	error1 := map[string]interface{}{"error": "message1"}
 
-	err := g.setOperationResponse(op, reflect.TypeOf(new(string)), "400", "application/json", "", nil, error1, nil)
+	err := g.setOperationResponse(op, reflect.TypeOf(new(string)), "400", []string{"application/json"}, "", nil, error1, nil)
 	assert.Nil(t, err)
 
 	// assert example set correctly
@@ -707,7 +1523,7 @@ func TestSetOperationResponseExamples(t *testing.T) {
 	error1 := map[string]interface{}{"error": "message1"}
 	error2 := map[string]interface{}{"error": "message2"}
 
-	err := g.setOperationResponse(op, reflect.TypeOf(new(string)), "400", "application/json", "", nil, nil,
+	err := g.setOperationResponse(op, reflect.TypeOf(new(string)), "400", []string{"application/json"}, "", nil, nil,
 		map[string]interface{}{
 			"one": error1,
 			"two": error2,
@@ -0,0 +1,68 @@
+//go:build go1.18
+
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	Page[T any] struct {
+		Items []T `json:"items"`
+		Total int `json:"total"`
+	}
+	Result[T any] struct {
+		Data T `json:"data"`
+	}
+	Pair[K comparable, V any] struct {
+		Key   K `json:"key"`
+		Value V `json:"value"`
+	}
+)
+
+// TestGenericTypeName tests that the names of the
+// instantiated generic types are valid component names.
+func TestGenericTypeName(t *testing.T) {
+	g, err := NewGenerator(genConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "OpenapiPageOfY", g.typeName(rt(Page[Y]{})))
+
+	g.UseFullSchemaNames(false)
+
+	for _, tt := range []struct {
+		typ  reflect.Type
+		name string
+	}{
+		{rt(new(Page[Y])), "PageOfY"},
+		{rt(Page[*Y]{}), "PageOfY"},
+		{rt(Pair[string, *Y]{}), "PairOfStringAndY"},
+		{rt(Result[Page[Y]]{}), "ResultOfPageOfY"},
+		{rt(Page[[]Y]{}), "PageOfArrayOfY"},
+		{rt(Page[map[string]Y]{}), "PageOfMapOfStringToY"},
+		{rt(Page[interface{}]{}), "PageOfInterface"},
+	} {
+		assert.Equal(t, tt.name, g.typeName(tt.typ), tt.typ.String())
+	}
+	// The schemas are referenced with their names.
+	sor := g.newSchemaFromType(rt(Result[Page[Y]]{}))
+	if assert.NotNil(t, sor) {
+		assert.Equal(t, componentsSchemaPath+"ResultOfPageOfY", sor.Ref)
+	}
+	assert.Contains(t, g.API().Components.Schemas, "ResultOfPageOfY")
+	assert.Contains(t, g.API().Components.Schemas, "PageOfY")
+
+	// Custom naming function.
+	g.SetGenericTypeNamer(func(name string, args []string) string {
+		return name + "_" + strings.Join(args, "_")
+	})
+	assert.Equal(t, "Pair_String_Y", g.typeName(rt(Pair[string, Y]{})))
+
+	g.SetGenericTypeNamer(nil)
+	assert.Equal(t, "PairOfStringAndY", g.typeName(rt(Pair[string, Y]{})))
+}
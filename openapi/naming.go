@@ -0,0 +1,212 @@
+package openapi
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// SchemaNamer is the interface implemented by the
+// types that name the component schemas of the spec.
+type SchemaNamer interface {
+	SchemaName(t reflect.Type) string
+}
+
+// SchemaNamerFunc is an adapter to allow the use of
+// ordinary functions as SchemaNamer.
+type SchemaNamerFunc func(t reflect.Type) string
+
+// SchemaName implements SchemaNamer for SchemaNamerFunc.
+func (f SchemaNamerFunc) SchemaName(t reflect.Type) string {
+	return f(t)
+}
+
+// NamingStrategy represents a built-in strategy
+// used to name the component schemas.
+type NamingStrategy int
+
+// Built-in naming strategies.
+const (
+	// ShortNames names the schemas with the
+	// name of their type, such as Fruit.
+	ShortNames NamingStrategy = iota
+	// PackageNames prefixes the name of the type
+	// with the name of its package, such as
+	// MarketFruit.
+	PackageNames
+	// PathHashNames suffixes the name of the type
+	// with a hash of its full import path, such as
+	// Fruit_5f0e4a39.
+	PathHashNames
+)
+
+// GenericTypeNamer is the function that returns the
+// name of an instantiated generic type, from the name
+// of the generic type and the names of its arguments.
+type GenericTypeNamer func(name string, args []string) string
+
+// DefaultGenericTypeNamer is the default GenericTypeNamer,
+// that joins the name of the type and the names of its
+// arguments, such as PageOfFruit or PairOfKeyAndValue.
+func DefaultGenericTypeNamer(name string, args []string) string {
+	return name + "Of" + strings.Join(args, "And")
+}
+
+// NewSchemaNamer returns a SchemaNamer that use one of the
+// built-in strategies, and the function fn to name the
+// instantiations of the generic types. A nil function
+// default to DefaultGenericTypeNamer. The namer can be
+// used to decorate the built-in names, such as converting
+// them to snake_case.
+func NewSchemaNamer(s NamingStrategy, fn GenericTypeNamer) SchemaNamer {
+	if fn == nil {
+		fn = DefaultGenericTypeNamer
+	}
+	return SchemaNamerFunc(func(t reflect.Type) string {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.PkgPath() == "" {
+			return ""
+		}
+		return schemaName(t, s, fn)
+	})
+}
+
+// schemaName returns the name of the named type t
+// using the naming strategy s.
+func schemaName(t reflect.Type, s NamingStrategy, fn GenericTypeNamer) string {
+	name := t.String() // package.name.
+	sp := strings.Index(name, ".")
+	pkg := name[:sp]
+
+	// If the package is the main package, remove
+	// the package part from the name.
+	if pkg == "main" {
+		pkg = ""
+	}
+	typ := name[sp+1:]
+
+	// The name of an instantiated generic type
+	// contains the import path of its arguments,
+	// which cannot be used in a component name.
+	if strings.IndexByte(typ, '[') != -1 {
+		typ = genericTypeName(typ, fn)
+	}
+	switch s {
+	case ShortNames:
+		return strings.Title(typ)
+	case PathHashNames:
+		h := fnv.New32a()
+		h.Write([]byte(t.PkgPath() + "." + t.Name()))
+
+		return fmt.Sprintf("%s_%08x", strings.Title(typ), h.Sum32())
+	}
+	return strings.Title(pkg) + strings.Title(typ)
+}
+
+// genericTypeName returns the name of an instantiated
+// generic type, such as PageOfFruit for the type named
+// Page[github.com/acme/market.Fruit].
+func genericTypeName(s string, fn GenericTypeNamer) string {
+	i := strings.IndexByte(s, '[')
+	if i == -1 || !strings.HasSuffix(s, "]") {
+		return s
+	}
+	args := splitTypeArgs(s[i+1 : len(s)-1])
+	names := make([]string, len(args))
+
+	for j, arg := range args {
+		names[j] = typeArgName(arg, fn)
+	}
+	return fn(strings.Title(s[:i]), names)
+}
+
+// typeArgName returns the name of the argument of
+// an instantiated generic type, without its import
+// path. The pointers are ignored, and the names of
+// the composite types are made of their elements,
+// such as ArrayOfFruit for []Fruit.
+func typeArgName(s string, fn GenericTypeNamer) string {
+	s = strings.TrimLeft(s, "*")
+
+	switch {
+	case strings.HasPrefix(s, "map["):
+		if k := closingBracket(s, 3); k != -1 {
+			return "MapOf" + typeArgName(s[4:k], fn) + "To" + typeArgName(s[k+1:], fn)
+		}
+	case strings.HasPrefix(s, "["):
+		if k := closingBracket(s, 0); k != -1 {
+			return "ArrayOf" + typeArgName(s[k+1:], fn)
+		}
+	}
+	// Remove the import path of the type,
+	// without considering its own arguments.
+	end := strings.IndexByte(s, '[')
+	if end == -1 {
+		end = len(s)
+	}
+	if i := strings.LastIndexByte(s[:end], '/'); i != -1 {
+		s, end = s[i+1:], end-i-1
+	}
+	if i := strings.IndexByte(s[:end], '.'); i != -1 {
+		s = s[i+1:]
+	}
+	s = genericTypeName(s, fn)
+
+	// Remove the characters that cannot be used
+	// in a component name, such as the spaces of
+	// the unnamed interfaces and structs.
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, strings.Title(s))
+}
+
+// splitTypeArgs splits the list of arguments of
+// an instantiated generic type, ignoring the commas
+// of the nested types.
+func splitTypeArgs(s string) []string {
+	var (
+		args  []string
+		depth int
+		start int
+	)
+	for i, r := range s {
+		switch r {
+		case '[', '{', '(':
+			depth++
+		case ']', '}', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(args, strings.TrimSpace(s[start:]))
+}
+
+// closingBracket returns the index of the bracket
+// that closes the one at index i of the string s,
+// or -1 if the brackets are unbalanced.
+func closingBracket(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
@@ -1,5 +1,10 @@
 package openapi
 
+import (
+	"strings"
+	"unicode"
+)
+
 // OperationInfo represents the informations of an operation
 // that will be used when generating the OpenAPI specification.
 type OperationInfo struct {
@@ -9,12 +14,28 @@ type OperationInfo struct {
 	Headers           []*ResponseHeader
 	Summary           string
 	Description       string
+	Tags              []string
 	Deprecated        bool
 	InputModel        interface{}
+	OutputModel       interface{}
 	Responses         []*OperationResponse
+	ParameterRefs     []string
 	Security          []*SecurityRequirement
 	XCodeSamples      []*XCodeSample
 	XInternal         bool
+	Extensions        map[string]interface{}
+	// Document the operation of a route that
+	// has no tonic handler, using the input and
+	// output models.
+	Document bool
+	// Media types of the request body, which
+	// default to the media type of tonic.
+	Consumes []string
+	// Media types of the responses, which default
+	// to the media type of tonic. The media types
+	// of a specific response code can be overriden.
+	Produces           []string
+	ResponseMediaTypes map[string][]string
 }
 
 // ResponseHeader represents a single header that
@@ -23,6 +44,10 @@ type ResponseHeader struct {
 	Name        string
 	Description string
 	Model       interface{}
+	// Name of a header registered in the components,
+	// in which case the description and model are
+	// ignored.
+	Ref string
 }
 
 // OperationResponse represents a single response of an
@@ -36,4 +61,24 @@ type OperationResponse struct {
 	Headers     []*ResponseHeader
 	Example     interface{}
 	Examples    map[string]interface{}
+	// Name of a response registered in the components,
+	// in which case the other fields but the code are
+	// ignored.
+	Ref string
+}
+
+// OperationID returns a default operation ID made
+// of the method and the path of the operation, such
+// as getUsersId for GET /users/{id}.
+func OperationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+
+	fields := strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, f := range fields {
+		b.WriteString(strings.Title(f))
+	}
+	return b.String()
 }
@@ -0,0 +1,119 @@
+package openapi
+
+import "strings"
+
+// Paths of the components of a document,
+// used by the local references.
+const (
+	componentsParameterPath = "#/components/parameters/"
+	componentsResponsePath  = "#/components/responses/"
+	componentsHeaderPath    = "#/components/headers/"
+)
+
+// ResolveSchema returns either the inlined schema in
+// sor or the one referenced in the components of the
+// document. It returns nil if the reference is not
+// found, is not local to the document or is part of
+// a cycle of references.
+func (api *OpenAPI) ResolveSchema(sor *SchemaOrRef) *Schema {
+	var seen map[string]bool // followed references
+	for sor != nil && sor.Schema == nil {
+		if sor.Reference == nil || api == nil || api.Components == nil {
+			return nil
+		}
+		if !strings.HasPrefix(sor.Ref, componentsSchemaPath) || seen[sor.Ref] {
+			return nil
+		}
+		if seen == nil {
+			seen = make(map[string]bool)
+		}
+		seen[sor.Ref] = true
+		sor = api.Components.Schemas[strings.TrimPrefix(sor.Ref, componentsSchemaPath)]
+	}
+	if sor == nil {
+		return nil
+	}
+	return sor.Schema
+}
+
+// ResolveParameter returns either the inlined parameter
+// in por or the one referenced in the components of the
+// document.
+func (api *OpenAPI) ResolveParameter(por *ParameterOrRef) *Parameter {
+	var seen map[string]bool // followed references
+	for por != nil && por.Parameter == nil {
+		if por.Reference == nil || api == nil || api.Components == nil {
+			return nil
+		}
+		if !strings.HasPrefix(por.Ref, componentsParameterPath) || seen[por.Ref] {
+			return nil
+		}
+		if seen == nil {
+			seen = make(map[string]bool)
+		}
+		seen[por.Ref] = true
+		por = api.Components.Parameters[strings.TrimPrefix(por.Ref, componentsParameterPath)]
+	}
+	if por == nil {
+		return nil
+	}
+	return por.Parameter
+}
+
+// ResolveResponse returns either the inlined response
+// in ror or the one referenced in the components of the
+// document.
+func (api *OpenAPI) ResolveResponse(ror *ResponseOrRef) *Response {
+	var seen map[string]bool // followed references
+	for ror != nil && ror.Response == nil {
+		if ror.Reference == nil || api == nil || api.Components == nil {
+			return nil
+		}
+		if !strings.HasPrefix(ror.Ref, componentsResponsePath) || seen[ror.Ref] {
+			return nil
+		}
+		if seen == nil {
+			seen = make(map[string]bool)
+		}
+		seen[ror.Ref] = true
+		ror = api.Components.Responses[strings.TrimPrefix(ror.Ref, componentsResponsePath)]
+	}
+	if ror == nil {
+		return nil
+	}
+	return ror.Response
+}
+
+// ResolveHeader returns either the inlined header
+// in hor or the one referenced in the components of
+// the document.
+func (api *OpenAPI) ResolveHeader(hor *HeaderOrRef) *Header {
+	var seen map[string]bool // followed references
+	for hor != nil && hor.Header == nil {
+		if hor.Reference == nil || api == nil || api.Components == nil {
+			return nil
+		}
+		if !strings.HasPrefix(hor.Ref, componentsHeaderPath) || seen[hor.Ref] {
+			return nil
+		}
+		if seen == nil {
+			seen = make(map[string]bool)
+		}
+		seen[hor.Ref] = true
+		hor = api.Components.Headers[strings.TrimPrefix(hor.Ref, componentsHeaderPath)]
+	}
+	if hor == nil {
+		return nil
+	}
+	return hor.Header
+}
+
+// SchemaName returns the name of the component
+// referenced by sor, or an empty string if the
+// schema is inlined.
+func SchemaName(sor *SchemaOrRef) string {
+	if sor == nil || sor.Schema != nil || sor.Reference == nil {
+		return ""
+	}
+	return strings.TrimPrefix(sor.Ref, componentsSchemaPath)
+}
@@ -0,0 +1,48 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResolveRefs tests that the references to the
+// components of a document are resolved, and that
+// a cycle of references resolves to nil.
+func TestResolveRefs(t *testing.T) {
+	api := &OpenAPI{
+		Components: &Components{
+			Schemas: map[string]*SchemaOrRef{
+				"A":    {Reference: &Reference{Ref: "#/components/schemas/B"}},
+				"B":    {Reference: &Reference{Ref: "#/components/schemas/A"}},
+				"C":    {Reference: &Reference{Ref: "#/components/schemas/Self"}},
+				"Self": {Reference: &Reference{Ref: "#/components/schemas/Self"}},
+				"D":    {Reference: &Reference{Ref: "#/components/schemas/User"}},
+				"User": {Schema: &Schema{Type: "object"}},
+			},
+			Parameters: map[string]*ParameterOrRef{
+				"A": {Reference: &Reference{Ref: "#/components/parameters/B"}},
+				"B": {Reference: &Reference{Ref: "#/components/parameters/A"}},
+			},
+			Responses: map[string]*ResponseOrRef{
+				"A": {Reference: &Reference{Ref: "#/components/responses/B"}},
+				"B": {Reference: &Reference{Ref: "#/components/responses/A"}},
+			},
+			Headers: map[string]*HeaderOrRef{
+				"A": {Reference: &Reference{Ref: "#/components/headers/B"}},
+				"B": {Reference: &Reference{Ref: "#/components/headers/A"}},
+			},
+		},
+	}
+	schemaRef := func(name string) *SchemaOrRef {
+		return &SchemaOrRef{Reference: &Reference{Ref: "#/components/schemas/" + name}}
+	}
+	assert.Equal(t, "object", api.ResolveSchema(schemaRef("D")).Type)
+	assert.Nil(t, api.ResolveSchema(schemaRef("A")))
+	assert.Nil(t, api.ResolveSchema(schemaRef("C")))
+	assert.Nil(t, api.ResolveSchema(schemaRef("Unknown")))
+
+	assert.Nil(t, api.ResolveParameter(&ParameterOrRef{Reference: &Reference{Ref: "#/components/parameters/A"}}))
+	assert.Nil(t, api.ResolveResponse(&ResponseOrRef{Reference: &Reference{Ref: "#/components/responses/A"}}))
+	assert.Nil(t, api.ResolveHeader(&HeaderOrRef{Reference: &Reference{Ref: "#/components/headers/A"}}))
+}
@@ -1,6 +1,9 @@
 package openapi
 
-import "sort"
+import (
+	"reflect"
+	"sort"
+)
 
 var locationsOrder = map[string]int{
 	"path":   0,
@@ -63,3 +66,18 @@ func paramsOrderedBy(less ...paramLessFunc) *paramsMultiSorter {
 		less: less,
 	}
 }
+
+// SortedKeys returns the sorted keys of the map m,
+// which must be a map with string keys. It panics
+// if m is not a map.
+func SortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	return keys
+}
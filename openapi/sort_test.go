@@ -36,3 +36,15 @@ func byName(p1, p2 *ParameterOrRef) bool {
 func byLocation(p1, p2 *ParameterOrRef) bool {
 	return locationsOrder[p1.In] < locationsOrder[p2.In]
 }
+
+// TestSortedKeys tests that the keys of the maps
+// with string keys are returned in ascending order.
+func TestSortedKeys(t *testing.T) {
+	assert.Equal(t, []string{"200", "404", "default"}, SortedKeys(Responses{
+		"default": nil,
+		"404":     nil,
+		"200":     nil,
+	}))
+	assert.Equal(t, []string{"a", "b"}, SortedKeys(map[string]bool{"b": true, "a": false}))
+	assert.Empty(t, SortedKeys(map[string]*SchemaOrRef(nil)))
+}
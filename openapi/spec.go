@@ -1,18 +1,49 @@
 package openapi
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// extensionPrefix is the prefix of the
+// keys of the vendor extensions.
+const extensionPrefix = "x-"
 
 // OpenAPI represents the root document object of
 // an OpenAPI document.
 type OpenAPI struct {
-	OpenAPI    string                 `json:"openapi" yaml:"openapi"`
-	Info       *Info                  `json:"info" yaml:"info"`
-	Servers    []*Server              `json:"servers,omitempty" yaml:"servers,omitempty"`
-	Paths      Paths                  `json:"paths" yaml:"paths"`
-	Components *Components            `json:"components,omitempty" yaml:"components,omitempty"`
-	Tags       []*Tag                 `json:"tags,omitempty" yaml:"tags,omitempty"`
-	Security   []*SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
-	XTagGroups []*XTagGroup           `json:"x-tagGroups,omitempty" yaml:"x-tagGroups,omitempty"`
+	OpenAPI           string                 `json:"openapi" yaml:"openapi"`
+	Info              *Info                  `json:"info" yaml:"info"`
+	JSONSchemaDialect string                 `json:"jsonSchemaDialect,omitempty" yaml:"jsonSchemaDialect,omitempty"`
+	Servers           []*Server              `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths             Paths                  `json:"paths" yaml:"paths"`
+	Components        *Components            `json:"components,omitempty" yaml:"components,omitempty"`
+	Tags              []*Tag                 `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Security          []*SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	XTagGroups        []*XTagGroup           `json:"x-tagGroups,omitempty" yaml:"x-tagGroups,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for OpenAPI.
+func (api *OpenAPI) MarshalJSON() ([]byte, error) {
+	type openAPI OpenAPI
+	return marshalJSON((*openAPI)(api), api.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for OpenAPI.
+func (api *OpenAPI) MarshalYAML() (interface{}, error) {
+	type openAPI OpenAPI
+	return marshalYAML((*openAPI)(api)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for OpenAPI.
+func (api *OpenAPI) UnmarshalJSON(b []byte) error {
+	type openAPI OpenAPI
+	return unmarshalJSON(b, (*openAPI)(api), &api.Extensions)
 }
 
 // Components holds a set of reusable objects for different
@@ -24,6 +55,26 @@ type Components struct {
 	Examples        map[string]*ExampleOrRef        `json:"examples,omitempty" yaml:"examples,omitempty"`
 	Headers         map[string]*HeaderOrRef         `json:"headers,omitempty" yaml:"headers,omitempty"`
 	SecuritySchemes map[string]*SecuritySchemeOrRef `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Components.
+func (c *Components) MarshalJSON() ([]byte, error) {
+	type components Components
+	return marshalJSON((*components)(c), c.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Components.
+func (c *Components) MarshalYAML() (interface{}, error) {
+	type components Components
+	return marshalYAML((*components)(c)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Components.
+func (c *Components) UnmarshalJSON(b []byte) error {
+	type components Components
+	return unmarshalJSON(b, (*components)(c), &c.Extensions)
 }
 
 // Info represents the metadata of an API.
@@ -35,6 +86,26 @@ type Info struct {
 	License        *License `json:"license,omitempty" yaml:"license,omitempty"`
 	Version        string   `json:"version" yaml:"version"`
 	XLogo          *XLogo   `json:"x-logo,omitempty" yaml:"x-logo,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Info.
+func (i *Info) MarshalJSON() ([]byte, error) {
+	type info Info
+	return marshalJSON((*info)(i), i.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Info.
+func (i *Info) MarshalYAML() (interface{}, error) {
+	type info Info
+	return marshalYAML((*info)(i)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Info.
+func (i *Info) UnmarshalJSON(b []byte) error {
+	type info Info
+	return unmarshalJSON(b, (*info)(i), &i.Extensions)
 }
 
 // Contact represents the the contact informations
@@ -43,6 +114,26 @@ type Contact struct {
 	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
 	URL   string `json:"url,omitempty" yaml:"url,omitempty"`
 	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Contact.
+func (c *Contact) MarshalJSON() ([]byte, error) {
+	type contact Contact
+	return marshalJSON((*contact)(c), c.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Contact.
+func (c *Contact) MarshalYAML() (interface{}, error) {
+	type contact Contact
+	return marshalYAML((*contact)(c)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Contact.
+func (c *Contact) UnmarshalJSON(b []byte) error {
+	type contact Contact
+	return unmarshalJSON(b, (*contact)(c), &c.Extensions)
 }
 
 // License represents the license informations
@@ -50,6 +141,26 @@ type Contact struct {
 type License struct {
 	Name string `json:"name" yaml:"name"`
 	URL  string `json:"url,omitempty" yaml:"url,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for License.
+func (l *License) MarshalJSON() ([]byte, error) {
+	type license License
+	return marshalJSON((*license)(l), l.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for License.
+func (l *License) MarshalYAML() (interface{}, error) {
+	type license License
+	return marshalYAML((*license)(l)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for License.
+func (l *License) UnmarshalJSON(b []byte) error {
+	type license License
+	return unmarshalJSON(b, (*license)(l), &l.Extensions)
 }
 
 // Server represents a server.
@@ -57,6 +168,26 @@ type Server struct {
 	URL         string                     `json:"url" yaml:"url"`
 	Description string                     `json:"description,omitempty" yaml:"description,omitempty"`
 	Variables   map[string]*ServerVariable `json:"variables,omitempty" yaml:"variables,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Server.
+func (s *Server) MarshalJSON() ([]byte, error) {
+	type server Server
+	return marshalJSON((*server)(s), s.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Server.
+func (s *Server) MarshalYAML() (interface{}, error) {
+	type server Server
+	return marshalYAML((*server)(s)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Server.
+func (s *Server) UnmarshalJSON(b []byte) error {
+	type server Server
+	return unmarshalJSON(b, (*server)(s), &s.Extensions)
 }
 
 // ServerVariable represents a server variable for server
@@ -65,6 +196,26 @@ type ServerVariable struct {
 	Enum        []string `json:"enum,omitempty" yaml:"enum,omitempty"`
 	Default     string   `json:"default" yaml:"default"`
 	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for ServerVariable.
+func (sv *ServerVariable) MarshalJSON() ([]byte, error) {
+	type serverVariable ServerVariable
+	return marshalJSON((*serverVariable)(sv), sv.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for ServerVariable.
+func (sv *ServerVariable) MarshalYAML() (interface{}, error) {
+	type serverVariable ServerVariable
+	return marshalYAML((*serverVariable)(sv)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for ServerVariable.
+func (sv *ServerVariable) UnmarshalJSON(b []byte) error {
+	type serverVariable ServerVariable
+	return unmarshalJSON(b, (*serverVariable)(sv), &sv.Extensions)
 }
 
 // Paths represents the relative paths to the individual
@@ -87,6 +238,26 @@ type PathItem struct {
 	TRACE       *Operation        `json:"trace,omitempty" yaml:"trace,omitempty"`
 	Servers     []*Server         `json:"servers,omitempty" yaml:"servers,omitempty"`
 	Parameters  []*ParameterOrRef `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for PathItem.
+func (pi *PathItem) MarshalJSON() ([]byte, error) {
+	type pathItem PathItem
+	return marshalJSON((*pathItem)(pi), pi.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for PathItem.
+func (pi *PathItem) MarshalYAML() (interface{}, error) {
+	type pathItem PathItem
+	return marshalYAML((*pathItem)(pi)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for PathItem.
+func (pi *PathItem) UnmarshalJSON(b []byte) error {
+	type pathItem PathItem
+	return unmarshalJSON(b, (*pathItem)(pi), &pi.Extensions)
 }
 
 // Reference is a simple object to allow referencing
@@ -107,6 +278,26 @@ type Parameter struct {
 	Schema          *SchemaOrRef `json:"schema,omitempty" yaml:"schema,omitempty"`
 	Style           string       `json:"style,omitempty" yaml:"style,omitempty"`
 	Explode         bool         `json:"explode,omitempty" yaml:"explode,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Parameter.
+func (p *Parameter) MarshalJSON() ([]byte, error) {
+	type parameter Parameter
+	return marshalJSON((*parameter)(p), p.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Parameter.
+func (p *Parameter) MarshalYAML() (interface{}, error) {
+	type parameter Parameter
+	return marshalYAML((*parameter)(p)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Parameter.
+func (p *Parameter) UnmarshalJSON(b []byte) error {
+	type parameter Parameter
+	return unmarshalJSON(b, (*parameter)(p), &p.Extensions)
 }
 
 // ParameterOrRef represents a Parameter that can be inlined
@@ -124,11 +315,49 @@ func (por *ParameterOrRef) MarshalYAML() (interface{}, error) {
 	return por.Reference, nil
 }
 
+// MarshalJSON implements json.Marshaler for ParameterOrRef.
+func (por *ParameterOrRef) MarshalJSON() ([]byte, error) {
+	if por.Parameter != nil {
+		return json.Marshal(por.Parameter)
+	}
+	return json.Marshal(por.Reference)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for ParameterOrRef.
+func (por *ParameterOrRef) UnmarshalJSON(b []byte) error {
+	if isReference(b) {
+		por.Reference = &Reference{}
+		return json.Unmarshal(b, por.Reference)
+	}
+	por.Parameter = &Parameter{}
+	return json.Unmarshal(b, por.Parameter)
+}
+
 // RequestBody represents a request body.
 type RequestBody struct {
 	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
 	Content     map[string]*MediaType `json:"content" yaml:"content"`
 	Required    bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for RequestBody.
+func (rb *RequestBody) MarshalJSON() ([]byte, error) {
+	type requestBody RequestBody
+	return marshalJSON((*requestBody)(rb), rb.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for RequestBody.
+func (rb *RequestBody) MarshalYAML() (interface{}, error) {
+	type requestBody RequestBody
+	return marshalYAML((*requestBody)(rb)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for RequestBody.
+func (rb *RequestBody) UnmarshalJSON(b []byte) error {
+	type requestBody RequestBody
+	return unmarshalJSON(b, (*requestBody)(rb), &rb.Extensions)
 }
 
 // SchemaOrRef represents a Schema that can be inlined
@@ -141,11 +370,32 @@ type SchemaOrRef struct {
 // MarshalYAML implements yaml.Marshaler for SchemaOrRef.
 func (sor *SchemaOrRef) MarshalYAML() (interface{}, error) {
 	if sor.Schema != nil {
+		if sor.Schema.oas31 {
+			return newSchema31(sor.Schema), nil
+		}
 		return sor.Schema, nil
 	}
 	return sor.Reference, nil
 }
 
+// MarshalJSON implements json.Marshaler for SchemaOrRef.
+func (sor *SchemaOrRef) MarshalJSON() ([]byte, error) {
+	if sor.Schema != nil {
+		return json.Marshal(sor.Schema)
+	}
+	return json.Marshal(sor.Reference)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for SchemaOrRef.
+func (sor *SchemaOrRef) UnmarshalJSON(b []byte) error {
+	if isReference(b) {
+		sor.Reference = &Reference{}
+		return json.Unmarshal(b, sor.Reference)
+	}
+	sor.Schema = &Schema{}
+	return json.Unmarshal(b, sor.Schema)
+}
+
 // Schema represents the definition of input and output data
 // types of the API.
 type Schema struct {
@@ -153,9 +403,9 @@ type Schema struct {
 	// definition but their definitions were adjusted to the
 	// OpenAPI Specification.
 	Type                 string                  `json:"type,omitempty" yaml:"type,omitempty"`
-	AllOf                *SchemaOrRef            `json:"allOf,omitempty" yaml:"allOf,omitempty"`
-	OneOf                *SchemaOrRef            `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
-	AnyOf                *SchemaOrRef            `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	AllOf                []*SchemaOrRef          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	OneOf                []*SchemaOrRef          `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	AnyOf                []*SchemaOrRef          `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
 	Items                *SchemaOrRef            `json:"items,omitempty" yaml:"items,omitempty"`
 	Properties           map[string]*SchemaOrRef `json:"properties,omitempty" yaml:"properties,omitempty"`
 	AdditionalProperties *SchemaOrRef            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
@@ -163,14 +413,15 @@ type Schema struct {
 	Format               string                  `json:"format,omitempty" yaml:"format,omitempty"`
 	Default              interface{}             `json:"default,omitempty" yaml:"default,omitempty"`
 	Example              interface{}             `json:"example,omitempty" yaml:"example,omitempty"`
+	Discriminator        *Discriminator          `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
 
 	// The following properties are taken directly from the
 	// JSON Schema definition and follow the same specifications
 	Title            string        `json:"title,omitempty" yaml:"title,omitempty"`
-	MultipleOf       int           `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
-	Maximum          int           `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	MultipleOf       *float64      `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	Maximum          *float64      `json:"maximum,omitempty" yaml:"maximum,omitempty"`
 	ExclusiveMaximum bool          `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
-	Minimum          int           `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Minimum          *float64      `json:"minimum,omitempty" yaml:"minimum,omitempty"`
 	ExclusiveMinimum bool          `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
 	MaxLength        int           `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
 	MinLength        int           `json:"minLength,omitempty" yaml:"minLength,omitempty"`
@@ -184,6 +435,112 @@ type Schema struct {
 	Enum             []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
 	Nullable         bool          `json:"nullable,omitempty" yaml:"nullable,omitempty"`
 	Deprecated       bool          `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+
+	// Const is only supported by OpenAPI 3.1, and
+	// is omitted from the 3.0 representation.
+	Const interface{} `json:"-" yaml:"-"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+
+	// oas31 indicates that the schema must be
+	// marshaled using the OpenAPI 3.1 representation,
+	// with the optional $schema keyword dialect.
+	oas31   bool
+	dialect string
+}
+
+// schema is an alias of Schema without methods,
+// used to marshal its default representation.
+type schema Schema
+
+// MarshalYAML implements yaml.Marshaler for Schema.
+func (s *Schema) MarshalYAML() (interface{}, error) {
+	if s.oas31 {
+		return marshalYAML(newSchema31(s)), nil
+	}
+	return marshalYAML((*schema)(s)), nil
+}
+
+// MarshalJSON implements json.Marshaler for Schema.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.oas31 {
+		return marshalJSON(newSchema31(s), s.Extensions)
+	}
+	return marshalJSON((*schema)(s), s.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Schema.
+// The OpenAPI 3.1 representation of the schema is also
+// accepted, in which case the type arrays that include
+// "null" and the numeric exclusive bounds are converted
+// to their OpenAPI 3.0 equivalent.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	aux := struct {
+		*schema
+		Type             json.RawMessage `json:"type"`
+		ExclusiveMaximum json.RawMessage `json:"exclusiveMaximum"`
+		ExclusiveMinimum json.RawMessage `json:"exclusiveMinimum"`
+		Examples         []interface{}   `json:"examples"`
+		Const            interface{}     `json:"const"`
+	}{schema: (*schema)(s)}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if len(aux.Type) != 0 {
+		var types []string
+		if err := json.Unmarshal(aux.Type, &types); err != nil {
+			if err := json.Unmarshal(aux.Type, &s.Type); err != nil {
+				return err
+			}
+		}
+		for _, t := range types {
+			if t == "null" {
+				s.Nullable = true
+			} else if s.Type == "" {
+				s.Type = t
+			}
+		}
+	}
+	var err error
+	if s.Maximum, s.ExclusiveMaximum, err = exclusiveBound(aux.ExclusiveMaximum, s.Maximum); err != nil {
+		return err
+	}
+	if s.Minimum, s.ExclusiveMinimum, err = exclusiveBound(aux.ExclusiveMinimum, s.Minimum); err != nil {
+		return err
+	}
+	if s.Example == nil && len(aux.Examples) != 0 {
+		s.Example = aux.Examples[0]
+	}
+	s.Const = aux.Const
+
+	return unmarshalExtensions(b, (*schema)(s), &s.Extensions)
+}
+
+// exclusiveBound decodes the exclusive bound b of a
+// schema, which is either a boolean modifier of the
+// inclusive bound, or a number since OpenAPI 3.1.
+func exclusiveBound(b json.RawMessage, bound *float64) (*float64, bool, error) {
+	if len(b) == 0 {
+		return bound, false, nil
+	}
+	var exclusive bool
+	if err := json.Unmarshal(b, &exclusive); err == nil {
+		return bound, exclusive, nil
+	}
+	var f float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, false, err
+	}
+	return &f, true, nil
+}
+
+// Discriminator represents the information about the
+// property used to differentiate the alternative schemas
+// of a polymorphic schema.
+type Discriminator struct {
+	PropertyName string            `json:"propertyName" yaml:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
 }
 
 // Operation describes an API operation on a path.
@@ -200,6 +557,8 @@ type Operation struct {
 	Security     []*SecurityRequirement `json:"security" yaml:"security"`
 	XCodeSamples []*XCodeSample         `json:"x-codeSamples,omitempty" yaml:"x-codeSamples,omitempty"`
 	XInternal    bool                   `json:"x-internal,omitempty" yaml:"x-internal,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
 }
 
 // A workaround for missing omitnil functionality.
@@ -216,23 +575,33 @@ type operationNilOmitted struct {
 	Servers      []*Server         `json:"servers,omitempty" yaml:"servers,omitempty"`
 	XCodeSamples []*XCodeSample    `json:"x-codeSamples,omitempty" yaml:"x-codeSamples,omitempty"`
 	XInternal    bool              `json:"x-internal,omitempty" yaml:"x-internal,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
 }
 
 // MarshalYAML implements yaml.Marshaler for Operation.
 // Needed to marshall empty but non-null SecurityRequirements.
 func (o *Operation) MarshalYAML() (interface{}, error) {
 	if o.Security == nil {
-		return omitOperationNilFields(o), nil
+		return marshalYAML(omitOperationNilFields(o)), nil
 	}
-	return o, nil
+	type operation Operation
+	return marshalYAML((*operation)(o)), nil
 }
 
 // MarshalJSON excludes empty but non-null SecurityRequirements.
 func (o *Operation) MarshalJSON() ([]byte, error) {
 	if o.Security == nil {
-		return json.Marshal(omitOperationNilFields(o))
+		return marshalJSON(omitOperationNilFields(o), o.Extensions)
 	}
-	return json.Marshal(*o)
+	type operation Operation
+	return marshalJSON((*operation)(o), o.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Operation.
+func (o *Operation) UnmarshalJSON(b []byte) error {
+	type operation Operation
+	return unmarshalJSON(b, (*operation)(o), &o.Extensions)
 }
 
 func omitOperationNilFields(o *Operation) *operationNilOmitted {
@@ -248,6 +617,7 @@ func omitOperationNilFields(o *Operation) *operationNilOmitted {
 		Servers:      o.Servers,
 		XCodeSamples: o.XCodeSamples,
 		XInternal:    o.XInternal,
+		Extensions:   o.Extensions,
 	}
 }
 
@@ -271,11 +641,49 @@ func (ror *ResponseOrRef) MarshalYAML() (interface{}, error) {
 	return ror.Reference, nil
 }
 
+// MarshalJSON implements json.Marshaler for ResponseOrRef.
+func (ror *ResponseOrRef) MarshalJSON() ([]byte, error) {
+	if ror.Response != nil {
+		return json.Marshal(ror.Response)
+	}
+	return json.Marshal(ror.Reference)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for ResponseOrRef.
+func (ror *ResponseOrRef) UnmarshalJSON(b []byte) error {
+	if isReference(b) {
+		ror.Reference = &Reference{}
+		return json.Unmarshal(b, ror.Reference)
+	}
+	ror.Response = &Response{}
+	return json.Unmarshal(b, ror.Response)
+}
+
 // Response describes a single response from an API.
 type Response struct {
 	Description string                     `json:"description,omitempty" yaml:"description,omitempty"`
 	Headers     map[string]*HeaderOrRef    `json:"headers,omitempty" yaml:"headers,omitempty"`
 	Content     map[string]*MediaTypeOrRef `json:"content,omitempty" yaml:"content,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Response.
+func (r *Response) MarshalJSON() ([]byte, error) {
+	type response Response
+	return marshalJSON((*response)(r), r.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Response.
+func (r *Response) MarshalYAML() (interface{}, error) {
+	type response Response
+	return marshalYAML((*response)(r)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Response.
+func (r *Response) UnmarshalJSON(b []byte) error {
+	type response Response
+	return unmarshalJSON(b, (*response)(r), &r.Extensions)
 }
 
 // HeaderOrRef represents a Header that can be inlined
@@ -293,6 +701,24 @@ func (hor *HeaderOrRef) MarshalYAML() (interface{}, error) {
 	return hor.Reference, nil
 }
 
+// MarshalJSON implements json.Marshaler for HeaderOrRef.
+func (hor *HeaderOrRef) MarshalJSON() ([]byte, error) {
+	if hor.Header != nil {
+		return json.Marshal(hor.Header)
+	}
+	return json.Marshal(hor.Reference)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for HeaderOrRef.
+func (hor *HeaderOrRef) UnmarshalJSON(b []byte) error {
+	if isReference(b) {
+		hor.Reference = &Reference{}
+		return json.Unmarshal(b, hor.Reference)
+	}
+	hor.Header = &Header{}
+	return json.Unmarshal(b, hor.Header)
+}
+
 // Header represents an HTTP header.
 type Header struct {
 	Description     string       `json:"description,omitempty" yaml:"description,omitempty"`
@@ -300,6 +726,26 @@ type Header struct {
 	Deprecated      bool         `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
 	AllowEmptyValue bool         `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
 	Schema          *SchemaOrRef `json:"schema,omitempty" yaml:"schema,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Header.
+func (h *Header) MarshalJSON() ([]byte, error) {
+	type header Header
+	return marshalJSON((*header)(h), h.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Header.
+func (h *Header) MarshalYAML() (interface{}, error) {
+	type header Header
+	return marshalYAML((*header)(h)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Header.
+func (h *Header) UnmarshalJSON(b []byte) error {
+	type header Header
+	return unmarshalJSON(b, (*header)(h), &h.Extensions)
 }
 
 // MediaTypeOrRef represents a MediaType that can be inlined
@@ -317,12 +763,50 @@ func (mtor *MediaTypeOrRef) MarshalYAML() (interface{}, error) {
 	return mtor.Reference, nil
 }
 
+// MarshalJSON implements json.Marshaler for MediaTypeOrRef.
+func (mtor *MediaTypeOrRef) MarshalJSON() ([]byte, error) {
+	if mtor.MediaType != nil {
+		return json.Marshal(mtor.MediaType)
+	}
+	return json.Marshal(mtor.Reference)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for MediaTypeOrRef.
+func (mtor *MediaTypeOrRef) UnmarshalJSON(b []byte) error {
+	if isReference(b) {
+		mtor.Reference = &Reference{}
+		return json.Unmarshal(b, mtor.Reference)
+	}
+	mtor.MediaType = &MediaType{}
+	return json.Unmarshal(b, mtor.MediaType)
+}
+
 // MediaType represents the type of a media.
 type MediaType struct {
 	Schema   *SchemaOrRef             `json:"schema" yaml:"schema"`
 	Example  interface{}              `json:"example,omitempty" yaml:"example,omitempty"`
 	Examples map[string]*ExampleOrRef `json:"examples,omitempty" yaml:"examples,omitempty"`
 	Encoding map[string]*Encoding     `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for MediaType.
+func (mt *MediaType) MarshalJSON() ([]byte, error) {
+	type mediaType MediaType
+	return marshalJSON((*mediaType)(mt), mt.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for MediaType.
+func (mt *MediaType) MarshalYAML() (interface{}, error) {
+	type mediaType MediaType
+	return marshalYAML((*mediaType)(mt)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for MediaType.
+func (mt *MediaType) UnmarshalJSON(b []byte) error {
+	type mediaType MediaType
+	return unmarshalJSON(b, (*mediaType)(mt), &mt.Extensions)
 }
 
 // ExampleOrRef represents an Example that can be inlined
@@ -340,12 +824,50 @@ func (eor *ExampleOrRef) MarshalYAML() (interface{}, error) {
 	return eor.Reference, nil
 }
 
+// MarshalJSON implements json.Marshaler for ExampleOrRef.
+func (eor *ExampleOrRef) MarshalJSON() ([]byte, error) {
+	if eor.Example != nil {
+		return json.Marshal(eor.Example)
+	}
+	return json.Marshal(eor.Reference)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for ExampleOrRef.
+func (eor *ExampleOrRef) UnmarshalJSON(b []byte) error {
+	if isReference(b) {
+		eor.Reference = &Reference{}
+		return json.Unmarshal(b, eor.Reference)
+	}
+	eor.Example = &Example{}
+	return json.Unmarshal(b, eor.Example)
+}
+
 // Example represents the example of a media type.
 type Example struct {
 	Summary       string      `json:"summary,omitempty" yaml:"summary,omitempty"`
 	Description   string      `json:"description,omitempty" yaml:"description,omitempty"`
 	Value         interface{} `json:"value,omitempty" yaml:"value,omitempty"`
 	ExternalValue string      `json:"externalValue,omitempty" yaml:"externalValue,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Example.
+func (e *Example) MarshalJSON() ([]byte, error) {
+	type example Example
+	return marshalJSON((*example)(e), e.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Example.
+func (e *Example) MarshalYAML() (interface{}, error) {
+	type example Example
+	return marshalYAML((*example)(e)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Example.
+func (e *Example) UnmarshalJSON(b []byte) error {
+	type example Example
+	return unmarshalJSON(b, (*example)(e), &e.Extensions)
 }
 
 // Encoding represents a single encoding definition
@@ -356,12 +878,52 @@ type Encoding struct {
 	Style         string                  `json:"style,omitempty" yaml:"style,omitempty"`
 	Explode       bool                    `json:"explode,omitempty" yaml:"explode,omitempty"`
 	AllowReserved bool                    `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Encoding.
+func (e *Encoding) MarshalJSON() ([]byte, error) {
+	type encoding Encoding
+	return marshalJSON((*encoding)(e), e.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Encoding.
+func (e *Encoding) MarshalYAML() (interface{}, error) {
+	type encoding Encoding
+	return marshalYAML((*encoding)(e)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Encoding.
+func (e *Encoding) UnmarshalJSON(b []byte) error {
+	type encoding Encoding
+	return unmarshalJSON(b, (*encoding)(e), &e.Extensions)
 }
 
 // Tag represents the metadata of a single tag.
 type Tag struct {
 	Name        string `json:"name" yaml:"name"`
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for Tag.
+func (t *Tag) MarshalJSON() ([]byte, error) {
+	type tag Tag
+	return marshalJSON((*tag)(t), t.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for Tag.
+func (t *Tag) MarshalYAML() (interface{}, error) {
+	type tag Tag
+	return marshalYAML((*tag)(t)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Tag.
+func (t *Tag) UnmarshalJSON(b []byte) error {
+	type tag Tag
+	return unmarshalJSON(b, (*tag)(t), &t.Extensions)
 }
 
 // SecuritySchemeOrRef represents a SecurityScheme that can be inlined
@@ -379,6 +941,24 @@ func (sor *SecuritySchemeOrRef) MarshalYAML() (interface{}, error) {
 	return sor.Reference, nil
 }
 
+// MarshalJSON implements json.Marshaler for SecuritySchemeOrRef.
+func (sor *SecuritySchemeOrRef) MarshalJSON() ([]byte, error) {
+	if sor.SecurityScheme != nil {
+		return json.Marshal(sor.SecurityScheme)
+	}
+	return json.Marshal(sor.Reference)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for SecuritySchemeOrRef.
+func (sor *SecuritySchemeOrRef) UnmarshalJSON(b []byte) error {
+	if isReference(b) {
+		sor.Reference = &Reference{}
+		return json.Unmarshal(b, sor.Reference)
+	}
+	sor.SecurityScheme = &SecurityScheme{}
+	return json.Unmarshal(b, sor.SecurityScheme)
+}
+
 // SecurityScheme represents a security scheme that can be used by an operation.
 type SecurityScheme struct {
 	Type             string      `json:"type,omitempty" yaml:"type,omitempty"`
@@ -389,6 +969,26 @@ type SecurityScheme struct {
 	Name             string      `json:"name,omitempty" yaml:"name,omitempty"`
 	OpenIDConnectURL string      `json:"openIdConnectUrl,omitempty" yaml:"openIdConnectUrl,omitempty"`
 	Flows            *OAuthFlows `json:"flows,omitempty" yaml:"flows,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for SecurityScheme.
+func (ss *SecurityScheme) MarshalJSON() ([]byte, error) {
+	type securityScheme SecurityScheme
+	return marshalJSON((*securityScheme)(ss), ss.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for SecurityScheme.
+func (ss *SecurityScheme) MarshalYAML() (interface{}, error) {
+	type securityScheme SecurityScheme
+	return marshalYAML((*securityScheme)(ss)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for SecurityScheme.
+func (ss *SecurityScheme) UnmarshalJSON(b []byte) error {
+	type securityScheme SecurityScheme
+	return unmarshalJSON(b, (*securityScheme)(ss), &ss.Extensions)
 }
 
 // OAuthFlows represents all the supported OAuth flows.
@@ -397,6 +997,26 @@ type OAuthFlows struct {
 	Password          *OAuthFlow `json:"password,omitempty" yaml:"password,omitempty"`
 	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty" yaml:"clientCredentials,omitempty"`
 	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty" yaml:"authorizationCode,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for OAuthFlows.
+func (f *OAuthFlows) MarshalJSON() ([]byte, error) {
+	type oauthFlows OAuthFlows
+	return marshalJSON((*oauthFlows)(f), f.Extensions)
+}
+
+// MarshalYAML implements yaml.Marshaler for OAuthFlows.
+func (f *OAuthFlows) MarshalYAML() (interface{}, error) {
+	type oauthFlows OAuthFlows
+	return marshalYAML((*oauthFlows)(f)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for OAuthFlows.
+func (f *OAuthFlows) UnmarshalJSON(b []byte) error {
+	type oauthFlows OAuthFlows
+	return unmarshalJSON(b, (*oauthFlows)(f), &f.Extensions)
 }
 
 // OAuthFlow represents an OAuth security scheme.
@@ -405,21 +1025,181 @@ type OAuthFlow struct {
 	TokenURL         string            `json:"tokenUrl,omitempty" yaml:"tokenUrl,omitempty"`
 	RefreshURL       string            `json:"refreshUrl,omitempty" yaml:"refreshUrl,omitempty"`
 	Scopes           map[string]string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// MarshalJSON implements json.Marshaler for OAuthFlow.
+func (f *OAuthFlow) MarshalJSON() ([]byte, error) {
+	type oauthFlow OAuthFlow
+	return marshalJSON((*oauthFlow)(f), f.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler for OAuthFlow.
+func (f *OAuthFlow) UnmarshalJSON(b []byte) error {
+	type oauthFlow OAuthFlow
+	return unmarshalJSON(b, (*oauthFlow)(f), &f.Extensions)
 }
 
 // MarshalYAML implements yaml.Marshaler for OAuthFlow.
-func (f OAuthFlow) MarshalYAML() ([]byte, error) {
+func (f OAuthFlow) MarshalYAML() (interface{}, error) {
 	type flow OAuthFlow
 	if f.Scopes == nil {
 		// The field is REQUIRED and MAY be empty according to the spec.
 		f.Scopes = map[string]string{}
 	}
-	return json.Marshal(flow(f))
+	return marshalYAML((*flow)(&f)), nil
 }
 
 // SecurityRequirement represents the security object in the API specification.
 type SecurityRequirement map[string][]string
 
+// marshalJSON returns the JSON encoding of v, the
+// representation of a spec object, with the vendor
+// extensions ext inlined, such as with YAML. The
+// extensions that are not prefixed with x- or that
+// collide with a field of v are ignored.
+func marshalJSON(v interface{}, ext map[string]interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(ext) == 0 {
+		return b, err
+	}
+	valid := validExtensions(v, ext)
+	if len(valid) == 0 {
+		return b, nil
+	}
+	e, err := json.Marshal(valid)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 2 { // empty object
+		return e, nil
+	}
+	return append(append(b[:len(b)-1], ','), e[1:]...), nil
+}
+
+// marshalYAML returns the value to marshal to YAML
+// for v, a pointer to the representation of a spec
+// object. Like with JSON, its vendor extensions that
+// are not prefixed with x- or that collide with a
+// field of v are ignored.
+func marshalYAML(v interface{}) interface{} {
+	rv := reflect.ValueOf(v).Elem()
+	f := rv.FieldByName("Extensions")
+
+	ext, _ := f.Interface().(map[string]interface{})
+	valid := validExtensions(v, ext)
+	if len(valid) == len(ext) {
+		return v
+	}
+	cp := reflect.New(rv.Type())
+	cp.Elem().Set(rv)
+	cp.Elem().FieldByName("Extensions").Set(reflect.ValueOf(valid))
+
+	return cp.Interface()
+}
+
+// validExtensions returns the vendor extensions of
+// ext that can be inlined in the representation of
+// the spec object v.
+func validExtensions(v interface{}, ext map[string]interface{}) map[string]interface{} {
+	keys := fieldKeys(v)
+
+	valid := make(map[string]interface{}, len(ext))
+	for k, val := range ext {
+		if strings.HasPrefix(k, extensionPrefix) && !keys[k] {
+			valid[k] = val
+		}
+	}
+	return valid
+}
+
+// unmarshalJSON decodes the JSON encoding b of a spec
+// object into v, and its vendor extensions into ext.
+func unmarshalJSON(b []byte, v interface{}, ext *map[string]interface{}) error {
+	if err := json.Unmarshal(b, v); err != nil {
+		return err
+	}
+	return unmarshalExtensions(b, v, ext)
+}
+
+// unmarshalExtensions decodes the vendor extensions
+// of the JSON encoding b of the spec object v into ext.
+func unmarshalExtensions(b []byte, v interface{}, ext *map[string]interface{}) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	keys := fieldKeys(v)
+
+	for k, raw := range m {
+		if !strings.HasPrefix(k, extensionPrefix) || keys[k] {
+			continue
+		}
+		var val interface{}
+		if err := json.Unmarshal(raw, &val); err != nil {
+			return err
+		}
+		if *ext == nil {
+			*ext = make(map[string]interface{})
+		}
+		(*ext)[k] = val
+	}
+	return nil
+}
+
+// checkExtension returns an error if key cannot be
+// used as a vendor extension of the spec object v,
+// because it is not prefixed with x- or collides
+// with one of the fields of v, such as x-logo.
+func checkExtension(v interface{}, key string) error {
+	if !strings.HasPrefix(key, extensionPrefix) {
+		return fmt.Errorf("extension %s must start with %s", key, extensionPrefix)
+	}
+	if fieldKeys(v)[key] {
+		return fmt.Errorf("extension %s collides with a field of %T", key, v)
+	}
+	return nil
+}
+
+// keysCache is the cache of the keys of
+// the fields of the spec objects, indexed
+// by their type.
+var keysCache sync.Map
+
+// fieldKeys returns the set of the JSON keys
+// of the fields of the struct pointed by v.
+func fieldKeys(v interface{}) map[string]bool {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if keys, ok := keysCache.Load(t); ok {
+		return keys.(map[string]bool)
+	}
+	keys := make(map[string]bool)
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if name != "" && name != "-" {
+				keys[name] = true
+			}
+		}
+	}
+	keysCache.Store(t, keys)
+
+	return keys
+}
+
+// isReference returns whether the JSON object
+// b is a reference to another object.
+func isReference(b []byte) bool {
+	var ref struct {
+		Ref *string `json:"$ref"`
+	}
+	return json.Unmarshal(b, &ref) == nil && ref.Ref != nil
+}
+
 // XLogo represents the information about the x-logo extension.
 // See: https://github.com/Redocly/redoc/blob/master/docs/redoc-vendor-extensions.md#x-logo
 type XLogo struct {
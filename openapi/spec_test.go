@@ -1,8 +1,12 @@
 package openapi
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 // TestYAMLMarshalingRefs tests that spec types
@@ -66,3 +70,124 @@ func TestYAMLMarshalingTypes(t *testing.T) {
 		}
 	}
 }
+
+// TestMarshalingExtensions tests that the vendor
+// extensions of the spec types are inlined in their
+// JSON and YAML representations.
+func TestMarshalingExtensions(t *testing.T) {
+	ext := map[string]interface{}{"x-order": 1, "x-go-type": "time.Time"}
+
+	for _, tt := range []struct {
+		v    interface{}
+		json string
+	}{
+		{&Info{Title: "Test", Version: "1.0", Extensions: ext}, `{"title":"Test","version":"1.0","x-go-type":"time.Time","x-order":1}`},
+		{&Tag{Name: "test", Extensions: ext}, `{"name":"test","x-go-type":"time.Time","x-order":1}`},
+		{&Contact{Extensions: ext}, `{"x-go-type":"time.Time","x-order":1}`},
+		{&Contact{}, `{}`},
+		{&SchemaOrRef{Schema: &Schema{Type: "string", Extensions: ext}}, `{"type":"string","x-go-type":"time.Time","x-order":1}`},
+		{&SchemaOrRef{Schema: &Schema{Type: "string", Extensions: ext, oas31: true}}, `{"type":"string","x-go-type":"time.Time","x-order":1}`},
+		{&ParameterOrRef{Parameter: &Parameter{Name: "a", In: "query", Extensions: ext}}, `{"name":"a","in":"query","x-go-type":"time.Time","x-order":1}`},
+		{&ParameterOrRef{Reference: &Reference{Ref: "#/components/parameters/a"}}, `{"$ref":"#/components/parameters/a"}`},
+		{&Operation{ID: "Test", Extensions: ext}, `{"operationId":"Test","x-go-type":"time.Time","x-order":1}`},
+		{&Operation{ID: "Test", Security: []*SecurityRequirement{}, Extensions: ext}, `{"operationId":"Test","security":[],"x-go-type":"time.Time","x-order":1}`},
+	} {
+		b, err := json.Marshal(tt.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.JSONEq(t, tt.json, string(b))
+
+		b, err = yaml.Marshal(tt.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]interface{})
+		if err := yaml.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		b, err = json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.JSONEq(t, tt.json, string(b))
+	}
+	// The extensions that collide with a field
+	// or don't start with x- are ignored.
+	info := &Info{
+		Title:   "Test",
+		Version: "1.0",
+		XLogo:   &XLogo{URL: "logo.png"},
+		Extensions: map[string]interface{}{
+			"x-logo":  "other.png",
+			"title":   "Other",
+			"x-order": 1,
+		},
+	}
+	b, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, `{"title":"Test","version":"1.0","x-logo":{"url":"logo.png"},"x-order":1}`, string(b))
+
+	b, err = yaml.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "title: Test\nversion: \"1.0\"\nx-logo:\n  url: logo.png\nx-order: 1\n", string(b))
+
+	// The extensions are also ignored in the
+	// YAML representation of a schema.
+	b, err = yaml.Marshal(&Schema{Type: "string", Extensions: map[string]interface{}{"type": "integer", "x-order": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "type: string\nx-order: 1\n", string(b))
+}
+
+// TestUnmarshalingExtensions tests that the vendor
+// extensions of a JSON document are decoded in the
+// extensions of the spec types.
+func TestUnmarshalingExtensions(t *testing.T) {
+	doc := `{
+		"openapi": "3.0.1",
+		"info": {"title": "Test", "version": "1.0", "x-logo": {"url": "logo.png"}, "x-audience": "public"},
+		"paths": {
+			"/test": {
+				"get": {
+					"operationId": "Test",
+					"x-internal": true,
+					"x-rate-limit": 100,
+					"parameters": [
+						{"$ref": "#/components/parameters/a"},
+						{"name": "b", "in": "query", "schema": {"type": "string", "x-go-type": "time.Time"}}
+					]
+				}
+			}
+		},
+		"x-source": "test"
+	}`
+	var api OpenAPI
+	if err := json.Unmarshal([]byte(doc), &api); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]interface{}{"x-source": "test"}, api.Extensions)
+	assert.Equal(t, map[string]interface{}{"x-audience": "public"}, api.Info.Extensions)
+	assert.Equal(t, "logo.png", api.Info.XLogo.URL)
+
+	op := api.Paths["/test"].GET
+	assert.True(t, op.XInternal)
+	assert.Equal(t, map[string]interface{}{"x-rate-limit": float64(100)}, op.Extensions)
+
+	if assert.Len(t, op.Parameters, 2) {
+		assert.Nil(t, op.Parameters[0].Parameter)
+		assert.Equal(t, "#/components/parameters/a", op.Parameters[0].Ref)
+		assert.Equal(t, "time.Time", op.Parameters[1].Schema.Extensions["x-go-type"])
+	}
+	// The document is marshaled back as-is.
+	b, err := json.Marshal(&api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, doc, string(b))
+}
@@ -2,6 +2,7 @@ package openapi
 
 import (
 	"fmt"
+	"mime/multipart"
 	"net"
 	"net/url"
 	"reflect"
@@ -22,6 +23,7 @@ var (
 	tofNetIP          = reflect.TypeOf(net.IP{})
 	tofNetURL         = reflect.TypeOf(url.URL{})
 	tofEmptyInterface = reflect.TypeOf(new(interface{})).Elem()
+	tofFileHeader     = reflect.TypeOf(multipart.FileHeader{})
 
 	// Imported.
 	tofUUID = reflect.TypeOf(uuid.UUID{})
@@ -55,6 +57,14 @@ type Nullable interface {
 	Nullable() bool
 }
 
+// polymorphicType represents the registered
+// implementations of an interface type.
+type polymorphicType struct {
+	kind         string
+	propertyName string
+	mapping      map[string]reflect.Type
+}
+
 // InternalDataType represents an internal type.
 type InternalDataType int
 
@@ -151,6 +161,8 @@ func DataTypeFromType(t reflect.Type) DataType {
 		return TypeURL
 	case tofEmptyInterface:
 		return TypeAny
+	case tofFileHeader:
+		return TypeBinary
 	}
 	// Treat imported types.
 	if dt := isImportedType(t); dt != nil {
@@ -0,0 +1,334 @@
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes a violation of the rules
+// of the OpenAPI specification in a document. The pointer
+// is a JSON Pointer to the offending part of the document.
+type ValidationError struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// Error implements the builtin error interface for ValidationError.
+func (ve *ValidationError) Error() string {
+	return fmt.Sprintf("%s: pointer=%s", ve.Message, ve.Pointer)
+}
+
+// Validate checks the document api against the rules of
+// the OpenAPI specification that cannot be enforced when
+// the document is generated: the references must resolve
+// to a component of the document, the security requirements
+// must name a security scheme of the components, the path
+// template parameters must be declared by the operations,
+// the operation IDs must be unique, the examples must be
+// valid against their schema, and the responses must have
+// a description.
+func Validate(api *OpenAPI) []*ValidationError {
+	if api == nil {
+		return nil
+	}
+	sv := &specValidator{
+		api: api,
+		ids: make(map[string]string),
+	}
+	sv.security(api.Security, "/security")
+
+	for _, path := range SortedKeys(api.Paths) {
+		sv.pathItem(path, api.Paths[path], "/paths/"+JSONPointerToken(path))
+	}
+	if c := api.Components; c != nil {
+		for _, name := range SortedKeys(c.Schemas) {
+			sv.schema(c.Schemas[name], "/components/schemas/"+JSONPointerToken(name))
+		}
+		for _, name := range SortedKeys(c.Responses) {
+			sv.response(c.Responses[name], "/components/responses/"+JSONPointerToken(name))
+		}
+		for _, name := range SortedKeys(c.Parameters) {
+			sv.parameter(c.Parameters[name], "/components/parameters/"+JSONPointerToken(name))
+		}
+		for _, name := range SortedKeys(c.Headers) {
+			sv.header(c.Headers[name], "/components/headers/"+JSONPointerToken(name))
+		}
+		for _, name := range SortedKeys(c.Examples) {
+			if eor := c.Examples[name]; eor != nil && eor.Example == nil && eor.Reference != nil {
+				sv.ref(eor.Ref, "/components/examples/"+JSONPointerToken(name)+"/$ref")
+			}
+		}
+		for _, name := range SortedKeys(c.SecuritySchemes) {
+			if sor := c.SecuritySchemes[name]; sor != nil && sor.SecurityScheme == nil && sor.Reference != nil {
+				sv.ref(sor.Ref, "/components/securitySchemes/"+JSONPointerToken(name)+"/$ref")
+			}
+		}
+	}
+	return sv.errors
+}
+
+type specValidator struct {
+	api    *OpenAPI
+	ids    map[string]string // pointers of the operation IDs
+	errors []*ValidationError
+}
+
+func (sv *specValidator) error(pointer, format string, a ...interface{}) {
+	sv.errors = append(sv.errors, &ValidationError{
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (sv *specValidator) pathItem(path string, item *PathItem, pointer string) {
+	if item == nil {
+		return
+	}
+	sv.parameters(item.Parameters, pointer+"/parameters")
+
+	for _, mo := range []struct {
+		method string
+		op     *Operation
+	}{
+		{"get", item.GET},
+		{"put", item.PUT},
+		{"post", item.POST},
+		{"delete", item.DELETE},
+		{"options", item.OPTIONS},
+		{"head", item.HEAD},
+		{"patch", item.PATCH},
+		{"trace", item.TRACE},
+	} {
+		if mo.op != nil {
+			sv.operation(path, item, mo.op, pointer+"/"+mo.method)
+		}
+	}
+}
+
+func (sv *specValidator) operation(path string, item *PathItem, op *Operation, pointer string) {
+	if op.ID != "" {
+		if p, ok := sv.ids[op.ID]; ok {
+			sv.error(pointer+"/operationId", "duplicate operation ID %s, already used by %s", op.ID, p)
+		} else {
+			sv.ids[op.ID] = pointer
+		}
+	}
+	sv.parameters(op.Parameters, pointer+"/parameters")
+
+	// Every parameter of the path template must be
+	// declared by the operation or its path item.
+	for _, m := range paramsInPathRe.FindAllStringSubmatch(path, -1) {
+		if !sv.hasPathParam(op.Parameters, m[1]) && !sv.hasPathParam(item.Parameters, m[1]) {
+			sv.error(pointer+"/parameters", "path parameter %s is not declared", m[1])
+		}
+	}
+	if rb := op.RequestBody; rb != nil {
+		for _, mt := range SortedKeys(rb.Content) {
+			sv.mediaType(rb.Content[mt], pointer+"/requestBody/content/"+JSONPointerToken(mt))
+		}
+	}
+	for _, code := range SortedKeys(op.Responses) {
+		sv.response(op.Responses[code], pointer+"/responses/"+JSONPointerToken(code))
+	}
+	sv.security(op.Security, pointer+"/security")
+}
+
+func (sv *specValidator) hasPathParam(params []*ParameterOrRef, name string) bool {
+	for _, por := range params {
+		if p := sv.api.ResolveParameter(por); p != nil && p.In == "path" && p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (sv *specValidator) parameters(params []*ParameterOrRef, pointer string) {
+	for i, por := range params {
+		sv.parameter(por, pointer+"/"+strconv.Itoa(i))
+	}
+}
+
+func (sv *specValidator) parameter(por *ParameterOrRef, pointer string) {
+	switch {
+	case por == nil:
+	case por.Parameter != nil:
+		sv.schema(por.Schema, pointer+"/schema")
+	case por.Reference != nil:
+		sv.ref(por.Ref, pointer+"/$ref")
+	}
+}
+
+func (sv *specValidator) response(ror *ResponseOrRef, pointer string) {
+	switch {
+	case ror == nil:
+		return
+	case ror.Response == nil:
+		if ror.Reference != nil {
+			sv.ref(ror.Ref, pointer+"/$ref")
+		}
+		return
+	}
+	if ror.Description == "" {
+		sv.error(pointer+"/description", "response description is empty")
+	}
+	for _, name := range SortedKeys(ror.Headers) {
+		sv.header(ror.Headers[name], pointer+"/headers/"+JSONPointerToken(name))
+	}
+	for _, mt := range SortedKeys(ror.Content) {
+		p := pointer + "/content/" + JSONPointerToken(mt)
+
+		switch mtor := ror.Content[mt]; {
+		case mtor == nil:
+		case mtor.MediaType != nil:
+			sv.mediaType(mtor.MediaType, p)
+		case mtor.Reference != nil:
+			sv.ref(mtor.Ref, p+"/$ref")
+		}
+	}
+}
+
+func (sv *specValidator) header(hor *HeaderOrRef, pointer string) {
+	switch {
+	case hor == nil:
+	case hor.Header != nil:
+		sv.schema(hor.Schema, pointer+"/schema")
+	case hor.Reference != nil:
+		sv.ref(hor.Ref, pointer+"/$ref")
+	}
+}
+
+func (sv *specValidator) mediaType(m *MediaType, pointer string) {
+	if m == nil {
+		return
+	}
+	sv.schema(m.Schema, pointer+"/schema")
+	sv.example(m.Schema, m.Example, pointer+"/example")
+
+	for _, name := range SortedKeys(m.Examples) {
+		p := pointer + "/examples/" + JSONPointerToken(name)
+
+		switch eor := m.Examples[name]; {
+		case eor == nil:
+		case eor.Example != nil:
+			sv.example(m.Schema, eor.Value, p+"/value")
+		case eor.Reference != nil:
+			sv.ref(eor.Ref, p+"/$ref")
+		}
+	}
+}
+
+// schema checks the inlined schema sor and its
+// subschemas. The referenced schemas are checked
+// with the components.
+func (sv *specValidator) schema(sor *SchemaOrRef, pointer string) {
+	if sor == nil {
+		return
+	}
+	if sor.Schema == nil {
+		if sor.Reference != nil {
+			sv.ref(sor.Ref, pointer+"/$ref")
+		}
+		return
+	}
+	s := sor.Schema
+
+	if s.Pattern != "" {
+		if _, err := compilePattern(s.Pattern); err != nil {
+			sv.error(pointer+"/pattern", "invalid pattern: %s", err)
+		}
+	}
+	sv.schema(s.Items, pointer+"/items")
+	sv.schema(s.AdditionalProperties, pointer+"/additionalProperties")
+
+	for _, name := range SortedKeys(s.Properties) {
+		sv.schema(s.Properties[name], pointer+"/properties/"+JSONPointerToken(name))
+	}
+	for _, kw := range []struct {
+		name    string
+		schemas []*SchemaOrRef
+	}{
+		{"allOf", s.AllOf},
+		{"oneOf", s.OneOf},
+		{"anyOf", s.AnyOf},
+	} {
+		for i, sor := range kw.schemas {
+			sv.schema(sor, pointer+"/"+kw.name+"/"+strconv.Itoa(i))
+		}
+	}
+	if d := s.Discriminator; d != nil {
+		for _, k := range SortedKeys(d.Mapping) {
+			sv.ref(d.Mapping[k], pointer+"/discriminator/mapping/"+JSONPointerToken(k))
+		}
+	}
+	sv.example(sor, s.Example, pointer+"/example")
+}
+
+// example checks that the value v is valid against
+// the schema sor. The value is converted to its JSON
+// representation before the validation.
+func (sv *specValidator) example(sor *SchemaOrRef, v interface{}, pointer string) {
+	if sor == nil || v == nil {
+		return
+	}
+	v, err := jsonValue(v)
+	if err != nil {
+		sv.error(pointer, "invalid example: %s", err)
+		return
+	}
+	for _, ve := range ValidateValue(sv.api, sor, v, pointer) {
+		sv.error(ve.Pointer, "example does not match its schema: %s", ve.Message)
+	}
+}
+
+func (sv *specValidator) security(reqs []*SecurityRequirement, pointer string) {
+	for i, req := range reqs {
+		if req == nil {
+			continue
+		}
+		for _, name := range SortedKeys(*req) {
+			var ok bool
+			if c := sv.api.Components; c != nil {
+				_, ok = c.SecuritySchemes[name]
+			}
+			if !ok {
+				sv.error(pointer+"/"+strconv.Itoa(i)+"/"+JSONPointerToken(name), "undefined security scheme %s", name)
+			}
+		}
+	}
+}
+
+// ref checks that the local reference ref resolves to
+// a component of the document. The references to other
+// documents are ignored.
+func (sv *specValidator) ref(ref, pointer string) {
+	if !strings.HasPrefix(ref, "#/") {
+		return
+	}
+	var (
+		ok    bool
+		c     = sv.api.Components
+		parts = strings.SplitN(strings.TrimPrefix(ref, "#/"), "/", 3)
+	)
+	if c != nil && len(parts) == 3 && parts[0] == "components" {
+		name := strings.NewReplacer("~1", "/", "~0", "~").Replace(parts[2])
+
+		switch parts[1] {
+		case "schemas":
+			_, ok = c.Schemas[name]
+		case "responses":
+			_, ok = c.Responses[name]
+		case "parameters":
+			_, ok = c.Parameters[name]
+		case "examples":
+			_, ok = c.Examples[name]
+		case "headers":
+			_, ok = c.Headers[name]
+		case "securitySchemes":
+			_, ok = c.SecuritySchemes[name]
+		}
+	}
+	if !ok {
+		sv.error(pointer, "unresolved reference %s", ref)
+	}
+}
@@ -0,0 +1,127 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidate tests that the violations of the rules
+// of the specification are reported with a pointer to
+// the offending part of the document.
+func TestValidate(t *testing.T) {
+	str := &SchemaOrRef{Schema: &Schema{Type: "string"}}
+
+	api := &OpenAPI{
+		OpenAPI: Version30,
+		Info:    &Info{Title: "Test", Version: "1.0"},
+		Security: []*SecurityRequirement{
+			{"apiKey": []string{}},
+			{"oauth2": []string{"read"}},
+		},
+		Paths: Paths{
+			"/users/{id}": &PathItem{
+				GET: &Operation{
+					ID: "GetUser",
+					Responses: Responses{
+						"200": &ResponseOrRef{Response: &Response{
+							Description: "OK",
+							Content: map[string]*MediaTypeOrRef{
+								"application/json": {MediaType: &MediaType{
+									Schema:  &SchemaOrRef{Reference: &Reference{Ref: "#/components/schemas/User"}},
+									Example: map[string]interface{}{"name": 42},
+								}},
+							},
+						}},
+						"404": &ResponseOrRef{Reference: &Reference{Ref: "#/components/responses/NotFound"}},
+					},
+				},
+				DELETE: &Operation{
+					ID: "GetUser",
+					Parameters: []*ParameterOrRef{
+						{Parameter: &Parameter{Name: "id", In: "path", Required: true, Schema: str}},
+					},
+					Responses: Responses{
+						"204": &ResponseOrRef{Response: &Response{}},
+					},
+					Security: []*SecurityRequirement{
+						{"basic": []string{}},
+					},
+				},
+			},
+			"/groups/{id}": &PathItem{
+				Parameters: []*ParameterOrRef{
+					{Reference: &Reference{Ref: "#/components/parameters/id"}},
+				},
+				GET: &Operation{
+					ID: "GetGroup",
+					Responses: Responses{
+						"200": &ResponseOrRef{Response: &Response{
+							Description: "OK",
+							Content: map[string]*MediaTypeOrRef{
+								"application/json": {MediaType: &MediaType{
+									Schema: &SchemaOrRef{Reference: &Reference{Ref: "#/components/schemas/User"}},
+									Examples: map[string]*ExampleOrRef{
+										"valid":   {Example: &Example{Value: map[string]string{"name": "John"}}},
+										"missing": {Reference: &Reference{Ref: "#/components/examples/missing"}},
+									},
+								}},
+							},
+						}},
+					},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: map[string]*SchemaOrRef{
+				"User": {Schema: &Schema{
+					Type: "object",
+					Properties: map[string]*SchemaOrRef{
+						"name":  str,
+						"group": {Reference: &Reference{Ref: "#/components/schemas/Group"}},
+						"age":   {Schema: &Schema{Type: "integer", Minimum: float64Ptr(18), Example: 12}},
+						"code":  {Schema: &Schema{Type: "string", Pattern: "[a-z"}},
+					},
+				}},
+			},
+			Parameters: map[string]*ParameterOrRef{
+				"id": {Parameter: &Parameter{Name: "id", In: "path", Required: true, Schema: str}},
+			},
+			SecuritySchemes: map[string]*SecuritySchemeOrRef{
+				"apiKey": {SecurityScheme: &SecurityScheme{Type: "apiKey", Name: "X-API-Key", In: "header"}},
+			},
+		},
+	}
+	errs := Validate(api)
+
+	expected := []*ValidationError{
+		{"/security/1/oauth2", "undefined security scheme oauth2"},
+		{"/paths/~1groups~1{id}/get/responses/200/content/application~1json/examples/missing/$ref", "unresolved reference #/components/examples/missing"},
+		{"/paths/~1users~1{id}/get/parameters", "path parameter id is not declared"},
+		{"/paths/~1users~1{id}/get/responses/200/content/application~1json/example/name", "example does not match its schema: value must be of type string"},
+		{"/paths/~1users~1{id}/get/responses/404/$ref", "unresolved reference #/components/responses/NotFound"},
+		{"/paths/~1users~1{id}/delete/operationId", "duplicate operation ID GetUser, already used by /paths/~1users~1{id}/get"},
+		{"/paths/~1users~1{id}/delete/responses/204/description", "response description is empty"},
+		{"/paths/~1users~1{id}/delete/security/0/basic", "undefined security scheme basic"},
+		{"/components/schemas/User/properties/age/example", "example does not match its schema: value must be greater than or equal to 18"},
+		{"/components/schemas/User/properties/code/pattern", "invalid pattern: error parsing regexp: missing closing ]: `[a-z`"},
+		{"/components/schemas/User/properties/group/$ref", "unresolved reference #/components/schemas/Group"},
+	}
+	if assert.Len(t, errs, len(expected)) {
+		for i, e := range expected {
+			assert.Equal(t, e.Pointer, errs[i].Pointer)
+			assert.Equal(t, e.Message, errs[i].Message)
+		}
+	}
+	assert.Nil(t, Validate(nil))
+
+	// A generated specification is valid.
+	g := gen(t)
+	if _, err := g.AddOperation("/test/{a}", "GET", "", rt(&struct {
+		A string `path:"a"`
+		B int    `query:"b" validate:"max=10" example:"5"`
+	}{}), rt(&Y{}), &OperationInfo{ID: "Test", StatusCode: 200}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, Validate(g.API()))
+}
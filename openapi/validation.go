@@ -2,55 +2,291 @@ package openapi
 
 import (
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
+// ValidatorFunc translates a tag of the validator package,
+// with its parameter, to the keywords of the schema of a
+// field of type t.
+type ValidatorFunc func(schema *Schema, param string, t reflect.Type)
+
+// validators maps the tags of the validator package
+// to their translation. The tags that have no effect
+// on the schema are mapped to a nil function.
+var validators = map[string]ValidatorFunc{
+	// Bounds.
+	"eq":  setSchemaEq,
+	"len": setSchemaLen,
+	"max": setSchemaMax,
+	"lte": setSchemaMax,
+	"min": setSchemaMin,
+	"gte": setSchemaMin,
+	"lt":  setSchemaLt,
+	"gt":  setSchemaGt,
+
+	// Enumerations and collections.
+	"oneof":  setSchemaOneOf,
+	"unique": setSchemaUnique,
+
+	// Formats.
+	"email":                formatValidator("email"),
+	"uuid":                 formatValidator("uuid"),
+	"uuid3":                formatValidator("uuid"),
+	"uuid4":                formatValidator("uuid"),
+	"uuid5":                formatValidator("uuid"),
+	"uuid_rfc4122":         formatValidator("uuid"),
+	"uuid3_rfc4122":        formatValidator("uuid"),
+	"uuid4_rfc4122":        formatValidator("uuid"),
+	"uuid5_rfc4122":        formatValidator("uuid"),
+	"url":                  formatValidator("uri"),
+	"uri":                  formatValidator("uri"),
+	"hostname":             formatValidator("hostname"),
+	"hostname_rfc1123":     formatValidator("hostname"),
+	"fqdn":                 formatValidator("hostname"),
+	"ipv4":                 formatValidator("ipv4"),
+	"ip4_addr":             formatValidator("ipv4"),
+	"ipv6":                 formatValidator("ipv6"),
+	"ip6_addr":             formatValidator("ipv6"),
+	"base64":               formatValidator("byte"),
+	"datetime":             setSchemaDatetime,
+	"alpha":                patternValidator(`^[a-zA-Z]+$`),
+	"alphanum":             patternValidator(`^[a-zA-Z0-9]+$`),
+	"numeric":              patternValidator(`^[-+]?[0-9]+(?:\.[0-9]+)?$`),
+	"number":               patternValidator(`^[0-9]+$`),
+	"hexadecimal":          patternValidator(`^(?:0[xX])?[0-9a-fA-F]+$`),
+	"hexcolor":             patternValidator(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`),
+	"lowercase":            patternValidator(`^[^A-Z]*$`),
+	"uppercase":            patternValidator(`^[^a-z]*$`),
+	"ascii":                patternValidator(`^[\x00-\x7F]*$`),
+	"e164":                 patternValidator(`^\+[1-9]?[0-9]{7,14}$`),
+	"startswith":           setSchemaPrefix,
+	"endswith":             setSchemaSuffix,
+	"contains":             setSchemaContains,
+	"required":             nil,
+	"omitempty":            nil,
+	"required_if":          nil,
+	"required_unless":      nil,
+	"required_with":        nil,
+	"required_with_all":    nil,
+	"required_without":     nil,
+	"required_without_all": nil,
+}
+
+// formatValidator returns a ValidatorFunc that sets
+// the format of the schema of a string.
+func formatValidator(format string) ValidatorFunc {
+	return func(schema *Schema, _ string, t reflect.Type) {
+		if isString(t) || schema.Type == "string" {
+			schema.Format = format
+		}
+	}
+}
+
+// patternValidator returns a ValidatorFunc that adds
+// the pattern to the schema of a string.
+func patternValidator(pattern string) ValidatorFunc {
+	return func(schema *Schema, _ string, t reflect.Type) {
+		setSchemaPattern(schema, pattern, t)
+	}
+}
+
+// setSchemaPattern sets the given pattern to the schema
+// of a string. If the schema already has a pattern, the
+// new one is added to the schemas that must all match.
+func setSchemaPattern(schema *Schema, pattern string, t reflect.Type) {
+	if !isString(t) && schema.Type != "string" {
+		return
+	}
+	if schema.Pattern == "" {
+		schema.Pattern = pattern
+		return
+	}
+	schema.AllOf = append(schema.AllOf, &SchemaOrRef{Schema: &Schema{
+		Pattern: pattern,
+	}})
+}
+
+func setSchemaPrefix(schema *Schema, prefix string, t reflect.Type) {
+	setSchemaPattern(schema, "^"+regexp.QuoteMeta(prefix), t)
+}
+
+func setSchemaSuffix(schema *Schema, suffix string, t reflect.Type) {
+	setSchemaPattern(schema, regexp.QuoteMeta(suffix)+"$", t)
+}
+
+func setSchemaContains(schema *Schema, s string, t reflect.Type) {
+	setSchemaPattern(schema, regexp.QuoteMeta(s), t)
+}
+
+// datetimeFormats maps the layouts of the datetime
+// tag to the formats of the specification.
+var datetimeFormats = map[string]string{
+	"2006-01-02T15:04:05Z07:00": "date-time",
+	"2006-01-02":                "date",
+	"15:04:05":                  "time",
+}
+
+// setSchemaDatetime sets the format of the schema of
+// a string validated with the given time layout.
+func setSchemaDatetime(schema *Schema, layout string, t reflect.Type) {
+	if f, ok := datetimeFormats[layout]; ok {
+		formatValidator(f)(schema, layout, t)
+	}
+}
+
+// setSchemaOneOf sets the values of the oneof tag,
+// separated by spaces, to the enum of the schema. The
+// values that contain spaces are single-quoted.
+func setSchemaOneOf(schema *Schema, param string, t reflect.Type) {
+	if !isString(t) && !isNumber(t) {
+		return
+	}
+	var enum []interface{}
+	for _, s := range splitOneOf(param) {
+		v, err := stringToType(s, t)
+		if err != nil {
+			return
+		}
+		enum = append(enum, v)
+	}
+	schema.Enum = enum
+}
+
+// splitOneOf splits the parameter of the oneof tag.
+func splitOneOf(param string) []string {
+	var values []string
+	for param = strings.TrimSpace(param); param != ""; param = strings.TrimSpace(param) {
+		if param[0] == '\'' {
+			if i := strings.IndexByte(param[1:], '\''); i != -1 {
+				values = append(values, param[1:i+1])
+				param = param[i+2:]
+				continue
+			}
+		}
+		i := strings.IndexByte(param, ' ')
+		if i == -1 {
+			i = len(param)
+		}
+		values = append(values, param[:i])
+		param = param[i:]
+	}
+	return values
+}
+
+// setSchemaUnique sets the uniqueItems property
+// of the schema of an array.
+func setSchemaUnique(schema *Schema, _ string, t reflect.Type) {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		schema.UniqueItems = true
+	}
+}
+
 // setSchemaMax sets the given maximum to the appropriate
 // schema field based on the given type.
-func setSchemaMax(schema *Schema, max int, t reflect.Type) {
+func setSchemaMax(schema *Schema, param string, t reflect.Type) {
 	if isNumber(t) {
-		schema.Maximum = max
-	} else if isString(t) {
-		if max >= 0 {
-			schema.MaxLength = max
-		}
-	} else if isMap(t) {
-		if max >= 0 {
-			schema.MaxProperties = max
-		}
-	} else if t.Kind() == reflect.Slice {
-		if max >= 0 {
-			schema.MaxItems = max
+		if f, ok := parseNumber(param, t); ok {
+			schema.Maximum = &f
 		}
+	} else if n, err := strconv.Atoi(param); err == nil {
+		setSchemaMaxLength(schema, n, t)
 	}
 }
 
 // setSchemaMin sets the given minimum to the appropriate
 // schema field based on the given type.
-func setSchemaMin(schema *Schema, min int, t reflect.Type) {
+func setSchemaMin(schema *Schema, param string, t reflect.Type) {
 	if isNumber(t) {
-		schema.Minimum = min
-	} else if isString(t) {
-		if min >= 0 {
-			schema.MinLength = min
+		if f, ok := parseNumber(param, t); ok {
+			schema.Minimum = &f
 		}
-	} else if isMap(t) {
-		if min >= 0 {
-			schema.MinProperties = min
+	} else if n, err := strconv.Atoi(param); err == nil {
+		setSchemaMinLength(schema, n, t)
+	}
+}
+
+// setSchemaLt sets the given exclusive maximum to the
+// appropriate schema field based on the given type. The
+// bound of an integer or a length is made inclusive.
+func setSchemaLt(schema *Schema, param string, t reflect.Type) {
+	if isNumber(t) {
+		f, ok := parseNumber(param, t)
+		if !ok {
+			return
 		}
-	} else if t.Kind() == reflect.Slice {
-		if min >= 0 {
-			schema.MinItems = min
+		if isFloat(t) {
+			schema.ExclusiveMaximum = true
+		} else {
+			f--
+		}
+		schema.Maximum = &f
+	} else if n, err := strconv.Atoi(param); err == nil {
+		setSchemaMaxLength(schema, n-1, t)
+	}
+}
+
+// setSchemaGt sets the given exclusive minimum to the
+// appropriate schema field based on the given type. The
+// bound of an integer or a length is made inclusive.
+func setSchemaGt(schema *Schema, param string, t reflect.Type) {
+	if isNumber(t) {
+		f, ok := parseNumber(param, t)
+		if !ok {
+			return
 		}
+		if isFloat(t) {
+			schema.ExclusiveMinimum = true
+		} else {
+			f++
+		}
+		schema.Minimum = &f
+	} else if n, err := strconv.Atoi(param); err == nil {
+		setSchemaMinLength(schema, n+1, t)
+	}
+}
+
+// setSchemaMaxLength sets the given maximum length
+// to the schema of a string, a map or a slice.
+func setSchemaMaxLength(schema *Schema, max int, t reflect.Type) {
+	if max < 0 {
+		return
+	}
+	if isString(t) {
+		schema.MaxLength = max
+	} else if isMap(t) {
+		schema.MaxProperties = max
+	} else if t.Kind() == reflect.Slice {
+		schema.MaxItems = max
+	}
+}
+
+// setSchemaMinLength sets the given minimum length
+// to the schema of a string, a map or a slice.
+func setSchemaMinLength(schema *Schema, min int, t reflect.Type) {
+	if min < 0 {
+		return
+	}
+	if isString(t) {
+		schema.MinLength = min
+	} else if isMap(t) {
+		schema.MinProperties = min
+	} else if t.Kind() == reflect.Slice {
+		schema.MinItems = min
 	}
 }
 
 // setSchemaEq sets the given equals value to the appropriate
 // schema field based on the given type.
-func setSchemaEq(schema *Schema, eq int, t reflect.Type) {
-	// For numbers and strings, equals tag would translate
-	// to the `const` property of the JSON Validation spec
-	// but OpenAPI doesn't support it.
+func setSchemaEq(schema *Schema, eq string, t reflect.Type) {
+	// For numbers and strings, equals tag translates to
+	// the `const` property of the JSON Validation spec,
+	// which is only supported by OpenAPI 3.1.
 	if isNumber(t) || isString(t) {
+		if v, err := stringToType(eq, t); err == nil {
+			schema.Const = v
+		}
 		return
 	}
 	setSchemaLen(schema, eq, t)
@@ -58,11 +294,26 @@ func setSchemaEq(schema *Schema, eq int, t reflect.Type) {
 
 // setSchemaLen sets the given len to the appropriate
 // schema field based on the given type.
-func setSchemaLen(schema *Schema, len int, t reflect.Type) {
+func setSchemaLen(schema *Schema, len string, t reflect.Type) {
 	setSchemaMax(schema, len, t)
 	setSchemaMin(schema, len, t)
 }
 
+// parseNumber parses the parameter of a tag
+// according to the kind of the number type t.
+func parseNumber(param string, t reflect.Type) (float64, bool) {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(param, 64)
+		return f, err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(param, 10, t.Bits())
+		return float64(n), err == nil
+	}
+	n, err := strconv.ParseInt(param, 10, t.Bits())
+	return float64(n), err == nil
+}
+
 // isString returns whether the given reflect type represents a string.
 func isString(typ reflect.Type) bool { return typ.Kind() == reflect.String }
 
@@ -90,3 +341,9 @@ func isNumber(typ reflect.Type) bool {
 	}
 	return false
 }
+
+// isFloat returns whether the given reflect
+// type represents a floating-point number.
+func isFloat(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64
+}
@@ -64,3 +64,55 @@ func TestSchemaValidation(t *testing.T) {
 		t.Error("expected json outputs to be equal")
 	}
 }
+
+// TestNumberValidation tests that the bounds of the
+// numbers are parsed according to the kind of the
+// field, and that the zero bounds are preserved.
+func TestNumberValidation(t *testing.T) {
+	type T struct {
+		A float64 `validate:"max=99.5,min=0"`
+		B float32 `validate:"gt=0.5,lt=1"`
+		C int     `validate:"gt=0,lt=10"`
+		D uint8   `validate:"max=255"`
+		E int8    `validate:"max=1000"` // ignored, overflows the field type
+		F int     `validate:"max=9.5"`  // ignored, not an integer
+		G *int64  `validate:"len=0"`
+	}
+	g := gen(t)
+	typ := rt(T{})
+
+	expected := []*Schema{
+		{Type: "number", Format: "double", Maximum: float64Ptr(99.5), Minimum: float64Ptr(0)},
+		{Type: "number", Format: "float", Minimum: float64Ptr(0.5), ExclusiveMinimum: true, Maximum: float64Ptr(1), ExclusiveMaximum: true},
+		{Type: "integer", Format: "int32", Minimum: float64Ptr(1), Maximum: float64Ptr(9)},
+		{Type: "integer", Format: "int32", Maximum: float64Ptr(255)},
+		{Type: "integer", Format: "int32"},
+		{Type: "integer", Format: "int32"},
+		{Type: "integer", Format: "int64", Nullable: true, Minimum: float64Ptr(0), Maximum: float64Ptr(0)},
+	}
+	for i, want := range expected {
+		sf := typ.Field(i)
+		sor := g.newSchemaFromStructField(sf, false, sf.Name, typ)
+		if assert.NotNil(t, sor, sf.Name) {
+			assert.Equal(t, want, sor.Schema, sf.Name)
+		}
+	}
+	b, err := json.Marshal(expected[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, `{"type":"number","format":"double","maximum":99.5,"minimum":0}`, string(b))
+
+	// The examples and the values are
+	// validated with the float bounds.
+	for i, want := range []float64{0, 0.75, 1, 0} {
+		assert.Equal(t, want, numberExample(expected[i]), typ.Field(i).Name)
+	}
+	assert.InDelta(t, 0.3, numberExample(&Schema{Minimum: float64Ptr(0.25), MultipleOf: float64Ptr(0.1)}), 1e-9)
+
+	assert.Empty(t, ValidateValue(nil, &SchemaOrRef{Schema: expected[0]}, 99.5, ""))
+	assert.Len(t, ValidateValue(nil, &SchemaOrRef{Schema: expected[1]}, 1.0, ""), 1)
+	assert.Empty(t, ValidateValue(nil, &SchemaOrRef{Schema: &Schema{Type: "number", MultipleOf: float64Ptr(0.1)}}, 0.3, ""))
+}
+
+func float64Ptr(f float64) *float64 { return &f }
@@ -0,0 +1,370 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// patterns is the cache of the compiled
+// patterns of the schemas, indexed by their
+// source.
+var patterns sync.Map
+
+// compiledPattern is the result of the
+// compilation of a pattern.
+type compiledPattern struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// compilePattern returns the compiled regular
+// expression of the pattern s. The results,
+// including the errors, are cached.
+func compilePattern(s string) (*regexp.Regexp, error) {
+	if v, ok := patterns.Load(s); ok {
+		cp := v.(*compiledPattern)
+		return cp.re, cp.err
+	}
+	re, err := regexp.Compile(s)
+	v, _ := patterns.LoadOrStore(s, &compiledPattern{re: re, err: err})
+	cp := v.(*compiledPattern)
+
+	return cp.re, cp.err
+}
+
+// ValueError describes a value that does
+// not conform to the schema it is validated
+// against. The pointer is a JSON Pointer to
+// the offending value.
+type ValueError struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// Error implements the builtin error interface for ValueError.
+func (ve *ValueError) Error() string {
+	return fmt.Sprintf("%s: pointer=%s", ve.Message, ve.Pointer)
+}
+
+// ValidateValue validates the value v against the
+// schema sor, and returns the list of violations.
+// The value is expected to be decoded from JSON, as
+// done by the encoding/json package with an empty
+// interface. The references to other schemas are
+// resolved using the components of the document api.
+// The pointer is used as the prefix of the pointers
+// of the returned errors.
+func ValidateValue(api *OpenAPI, sor *SchemaOrRef, v interface{}, pointer string) []*ValueError {
+	vv := &valueValidator{api: api}
+	vv.validate(sor, v, pointer)
+
+	return vv.errors
+}
+
+// jsonValue returns the JSON representation of the
+// value v, as decoded by the encoding/json package with
+// an empty interface.
+func jsonValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var jv interface{}
+	if err := json.Unmarshal(b, &jv); err != nil {
+		return nil, err
+	}
+	return jv, nil
+}
+
+// JSONPointerToken escapes the token s to be used
+// as a reference token of a JSON Pointer.
+func JSONPointerToken(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+type valueValidator struct {
+	api    *OpenAPI
+	errors []*ValueError
+}
+
+func (vv *valueValidator) error(pointer, format string, a ...interface{}) {
+	vv.errors = append(vv.errors, &ValueError{
+		Pointer: pointer,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (vv *valueValidator) validate(sor *SchemaOrRef, v interface{}, pointer string) {
+	schema := vv.api.ResolveSchema(sor)
+	if schema == nil {
+		return
+	}
+	if v == nil {
+		if !schema.Nullable && schema.Type != "" {
+			vv.error(pointer, "value must not be null")
+		}
+		return
+	}
+	if !vv.validateType(schema, v, pointer) {
+		return
+	}
+	if len(schema.Enum) != 0 {
+		found := false
+		for _, e := range schema.Enum {
+			if valuesEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vv.error(pointer, "value must be one of %v", schema.Enum)
+		}
+	}
+	if schema.Const != nil && !valuesEqual(schema.Const, v) {
+		vv.error(pointer, "value must be equal to %v", schema.Const)
+	}
+	switch val := v.(type) {
+	case float64:
+		vv.validateNumber(schema, val, pointer)
+	case string:
+		vv.validateString(schema, val, pointer)
+	case []interface{}:
+		vv.validateArray(schema, val, pointer)
+	case map[string]interface{}:
+		vv.validateObject(schema, val, pointer)
+	}
+	for _, s := range schema.AllOf {
+		vv.validate(s, v, pointer)
+	}
+	// The discriminator selects the only schema an object
+	// is validated against, since the implementations may
+	// share their properties.
+	if m, ok := v.(map[string]interface{}); ok && schema.Discriminator != nil &&
+		(len(schema.OneOf) != 0 || len(schema.AnyOf) != 0) {
+		vv.validateDiscriminated(schema.Discriminator, m, pointer)
+		return
+	}
+	if len(schema.OneOf) != 0 {
+		if n := vv.countValid(schema.OneOf, v, pointer); n != 1 {
+			vv.error(pointer, "value must match exactly one schema, matched %d", n)
+		}
+	}
+	if len(schema.AnyOf) != 0 {
+		if n := vv.countValid(schema.AnyOf, v, pointer); n == 0 {
+			vv.error(pointer, "value must match at least one schema")
+		}
+	}
+}
+
+// validateDiscriminated validates the object m against
+// the schema mapped to the value of the discriminator
+// property, or against the component schema named after
+// the value if the mapping has no such key.
+func (vv *valueValidator) validateDiscriminated(d *Discriminator, m map[string]interface{}, pointer string) {
+	p := pointer + "/" + JSONPointerToken(d.PropertyName)
+
+	pv, ok := m[d.PropertyName]
+	if !ok {
+		vv.error(p, "property is required")
+		return
+	}
+	name, ok := pv.(string)
+	if !ok {
+		vv.error(p, "value must be a string")
+		return
+	}
+	ref, ok := d.Mapping[name]
+	if !ok {
+		ref = name
+	}
+	if !strings.HasPrefix(ref, componentsSchemaPath) {
+		ref = componentsSchemaPath + ref
+	}
+	sor := &SchemaOrRef{Reference: &Reference{Ref: ref}}
+	if vv.api.ResolveSchema(sor) == nil {
+		vv.error(p, "value %q does not match any schema", name)
+		return
+	}
+	vv.validate(sor, m, pointer)
+}
+
+// countValid returns the number of schemas
+// against which the value v is valid.
+func (vv *valueValidator) countValid(schemas []*SchemaOrRef, v interface{}, pointer string) int {
+	var n int
+	for _, s := range schemas {
+		sub := &valueValidator{api: vv.api}
+		sub.validate(s, v, pointer)
+		if len(sub.errors) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// validateType returns whether the value v
+// is of the type described by the schema.
+func (vv *valueValidator) validateType(schema *Schema, v interface{}, pointer string) bool {
+	var ok bool
+
+	switch schema.Type {
+	case "":
+		return true
+	case "string":
+		_, ok = v.(string)
+	case "boolean":
+		_, ok = v.(bool)
+	case "number":
+		_, ok = v.(float64)
+	case "integer":
+		var f float64
+		if f, ok = v.(float64); ok {
+			ok = f == float64(int64(f))
+		}
+	case "array":
+		_, ok = v.([]interface{})
+	case "object":
+		_, ok = v.(map[string]interface{})
+	default:
+		return true
+	}
+	if !ok {
+		vv.error(pointer, "value must be of type %s", schema.Type)
+	}
+	return ok
+}
+
+func (vv *valueValidator) validateNumber(schema *Schema, f float64, pointer string) {
+	if schema.Maximum != nil {
+		max := *schema.Maximum
+		if schema.ExclusiveMaximum && f >= max {
+			vv.error(pointer, "value must be lower than %v", max)
+		} else if f > max {
+			vv.error(pointer, "value must be lower than or equal to %v", max)
+		}
+	}
+	if schema.Minimum != nil {
+		min := *schema.Minimum
+		if schema.ExclusiveMinimum && f <= min {
+			vv.error(pointer, "value must be greater than %v", min)
+		} else if f < min {
+			vv.error(pointer, "value must be greater than or equal to %v", min)
+		}
+	}
+	if m := schema.MultipleOf; m != nil && *m > 0 {
+		// The quotient of a multiple of a decimal
+		// number may not be an exact integer.
+		if q := f / *m; math.Abs(q-math.Round(q)) > 1e-9 {
+			vv.error(pointer, "value must be a multiple of %v", *m)
+		}
+	}
+}
+
+func (vv *valueValidator) validateString(schema *Schema, s string, pointer string) {
+	l := utf8.RuneCountInString(s)
+
+	if schema.MaxLength != 0 && l > schema.MaxLength {
+		vv.error(pointer, "length must be lower than or equal to %d", schema.MaxLength)
+	}
+	if schema.MinLength != 0 && l < schema.MinLength {
+		vv.error(pointer, "length must be greater than or equal to %d", schema.MinLength)
+	}
+	// An invalid pattern is reported once by
+	// Validate, when the document is checked.
+	if schema.Pattern != "" {
+		re, err := compilePattern(schema.Pattern)
+		if err == nil && !re.MatchString(s) {
+			vv.error(pointer, "value must match pattern %s", schema.Pattern)
+		}
+	}
+}
+
+func (vv *valueValidator) validateArray(schema *Schema, a []interface{}, pointer string) {
+	if schema.MaxItems != 0 && len(a) > schema.MaxItems {
+		vv.error(pointer, "number of items must be lower than or equal to %d", schema.MaxItems)
+	}
+	if schema.MinItems != 0 && len(a) < schema.MinItems {
+		vv.error(pointer, "number of items must be greater than or equal to %d", schema.MinItems)
+	}
+	if schema.UniqueItems {
+	loop:
+		for i := range a {
+			for j := i + 1; j < len(a); j++ {
+				if valuesEqual(a[i], a[j]) {
+					vv.error(pointer, "items must be unique")
+					break loop
+				}
+			}
+		}
+	}
+	if schema.Items != nil {
+		for i, item := range a {
+			vv.validate(schema.Items, item, pointer+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+func (vv *valueValidator) validateObject(schema *Schema, m map[string]interface{}, pointer string) {
+	if schema.MaxProperties != 0 && len(m) > schema.MaxProperties {
+		vv.error(pointer, "number of properties must be lower than or equal to %d", schema.MaxProperties)
+	}
+	if schema.MinProperties != 0 && len(m) < schema.MinProperties {
+		vv.error(pointer, "number of properties must be greater than or equal to %d", schema.MinProperties)
+	}
+	for _, name := range schema.Required {
+		if _, ok := m[name]; !ok {
+			vv.error(pointer+"/"+JSONPointerToken(name), "property is required")
+		}
+	}
+	// Iterate over the properties in a
+	// deterministic order.
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := pointer + "/" + JSONPointerToken(name)
+		if ps, ok := schema.Properties[name]; ok {
+			vv.validate(ps, m[name], p)
+		} else if schema.AdditionalProperties != nil {
+			vv.validate(schema.AdditionalProperties, m[name], p)
+		}
+	}
+}
+
+// valuesEqual returns whether the values a and b
+// are equal. Numbers are compared regardless of
+// their Go type.
+func valuesEqual(a, b interface{}) bool {
+	fa, oka := toFloat(a)
+	fb, okb := toFloat(b)
+	if oka || okb {
+		return oka && okb && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converts the number v to a float64.
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,134 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateValue tests that a value decoded
+// from JSON is validated against a schema and that
+// every violation is reported with its pointer.
+func TestValidateValue(t *testing.T) {
+	type Item struct {
+		Name string `json:"name" validate:"required,min=3"`
+	}
+	type T struct {
+		A string  `json:"a" validate:"required" enum:"foo,bar"`
+		B int     `json:"b" validate:"max=10"`
+		C *string `json:"c"`
+		D string  `json:"d" validate:"required"`
+		E []Item  `json:"e"`
+		G int     `json:"g"`
+	}
+	g := gen(t)
+	sor := g.newSchemaFromType(rt(T{}))
+	assert.Len(t, g.Errors(), 0)
+
+	var v interface{}
+	err := json.Unmarshal([]byte(`{
+		"a": "baz",
+		"b": 12,
+		"c": null,
+		"e": [{"name": "ok!"}, {"name": "ko"}, {}],
+		"g": 1.5
+	}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := ValidateValue(g.API(), sor, v, "/body")
+
+	var pointers []string
+	for _, e := range errs {
+		pointers = append(pointers, e.Pointer)
+	}
+	assert.ElementsMatch(t, []string{
+		"/body/a",
+		"/body/b",
+		"/body/d",
+		"/body/e/1/name",
+		"/body/e/2/name",
+		"/body/g",
+	}, pointers)
+
+	err = json.Unmarshal([]byte(`{"a":"foo","c":"x","d":"","e":[],"g":2}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, ValidateValue(g.API(), sor, v, ""))
+
+	// The patterns are compiled once, and an invalid
+	// pattern is ignored by the value validation.
+	pattern := &SchemaOrRef{Schema: &Schema{Type: "string", Pattern: "^[a-z]+$"}}
+	assert.Len(t, ValidateValue(nil, pattern, "ABC", ""), 1)
+	assert.Empty(t, ValidateValue(nil, pattern, "abc", ""))
+	_, ok := patterns.Load("^[a-z]+$")
+	assert.True(t, ok)
+
+	invalid := &SchemaOrRef{Schema: &Schema{Type: "string", Pattern: "[a-z"}}
+	assert.Empty(t, ValidateValue(nil, invalid, "abc", ""))
+
+	assert.Equal(t, "a~1b~0c", JSONPointerToken("a/b~c"))
+}
+
+type (
+	Shape interface {
+		Area() float64
+	}
+	Circle struct {
+		Kind string  `json:"kind"`
+		ID   string  `json:"id"`
+		Size float64 `json:"size"`
+	}
+	Square struct {
+		Kind string  `json:"kind"`
+		ID   string  `json:"id"`
+		Size float64 `json:"size" validate:"max=10"`
+	}
+)
+
+func (Circle) Area() float64 { return 0 }
+func (Square) Area() float64 { return 0 }
+
+// TestValidateDiscriminatedValue tests that a value is
+// validated only against the schema selected by the
+// discriminator, even if the implementations overlap.
+func TestValidateDiscriminatedValue(t *testing.T) {
+	g := gen(t)
+
+	err := g.RegisterOneOf(rt((*Shape)(nil)).Elem(), "kind", map[string]interface{}{
+		"circle": Circle{},
+		"square": Square{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type T struct {
+		S Shape `json:"s"`
+	}
+	sor := g.newSchemaFromType(rt(T{}))
+	assert.Len(t, g.Errors(), 0)
+
+	for _, tt := range []struct {
+		value    string
+		pointers []string
+	}{
+		{`{"s":{"kind":"circle","id":"1","size":42}}`, nil},
+		{`{"s":{"kind":"square","id":"1","size":2}}`, nil},
+		{`{"s":{"kind":"square","id":"1","size":42}}`, []string{"/s/size"}},
+		{`{"s":{"kind":"triangle","id":"1"}}`, []string{"/s/kind"}},
+		{`{"s":{"kind":1}}`, []string{"/s/kind"}},
+		{`{"s":{"id":"1"}}`, []string{"/s/kind"}},
+	} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.value), &v); err != nil {
+			t.Fatal(err)
+		}
+		var pointers []string
+		for _, e := range ValidateValue(g.API(), sor, v, "") {
+			pointers = append(pointers, e.Pointer)
+		}
+		assert.Equal(t, tt.pointers, pointers, tt.value)
+	}
+}
@@ -0,0 +1,334 @@
+package openapi
+
+// Versions of the OpenAPI specification
+// supported by the generator.
+const (
+	Version30 = "3.0.1"
+	Version31 = "3.1.0"
+)
+
+// jsonSchemaDialect31 is the default dialect used by
+// the schemas of an OpenAPI 3.1 document.
+const jsonSchemaDialect31 = "https://spec.openapis.org/oas/3.1/dialect/base"
+
+// schema31 represents a Schema marshaled according
+// to the OpenAPI 3.1 specification, which is a superset
+// of the JSON Schema Draft 2020-12.
+type schema31 struct {
+	Dialect              string                  `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Type                 interface{}             `json:"type,omitempty" yaml:"type,omitempty"`
+	AllOf                []*SchemaOrRef          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	OneOf                []*SchemaOrRef          `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	AnyOf                []*SchemaOrRef          `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	Items                *SchemaOrRef            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*SchemaOrRef `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *SchemaOrRef            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Description          string                  `json:"description,omitempty" yaml:"description,omitempty"`
+	Format               string                  `json:"format,omitempty" yaml:"format,omitempty"`
+	Default              interface{}             `json:"default,omitempty" yaml:"default,omitempty"`
+	Examples             []interface{}           `json:"examples,omitempty" yaml:"examples,omitempty"`
+	Discriminator        *Discriminator          `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
+	Title                string                  `json:"title,omitempty" yaml:"title,omitempty"`
+	MultipleOf           *float64                `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	Maximum              *float64                `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMaximum     *float64                `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	Minimum              *float64                `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	ExclusiveMinimum     *float64                `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	MaxLength            int                     `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinLength            int                     `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	Pattern              string                  `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MaxItems             int                     `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinItems             int                     `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	UniqueItems          bool                    `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	MaxProperties        int                     `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	MinProperties        int                     `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+	Required             []string                `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum                 []interface{}           `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Const                interface{}             `json:"const,omitempty" yaml:"const,omitempty"`
+	Deprecated           bool                    `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+
+	Extensions map[string]interface{} `json:"-" yaml:",inline"`
+}
+
+// newSchema31 returns the OpenAPI 3.1 representation
+// of the schema s.
+func newSchema31(s *Schema) *schema31 {
+	s31 := &schema31{
+		Dialect:              s.dialect,
+		AllOf:                s.AllOf,
+		OneOf:                s.OneOf,
+		AnyOf:                s.AnyOf,
+		Items:                s.Items,
+		Properties:           s.Properties,
+		AdditionalProperties: s.AdditionalProperties,
+		Description:          s.Description,
+		Format:               s.Format,
+		Default:              s.Default,
+		Discriminator:        s.Discriminator,
+		Title:                s.Title,
+		Extensions:           s.Extensions,
+		MultipleOf:           s.MultipleOf,
+		Maximum:              s.Maximum,
+		Minimum:              s.Minimum,
+		MaxLength:            s.MaxLength,
+		MinLength:            s.MinLength,
+		Pattern:              s.Pattern,
+		MaxItems:             s.MaxItems,
+		MinItems:             s.MinItems,
+		UniqueItems:          s.UniqueItems,
+		MaxProperties:        s.MaxProperties,
+		MinProperties:        s.MinProperties,
+		Required:             s.Required,
+		Enum:                 s.Enum,
+		Const:                s.Const,
+		Deprecated:           s.Deprecated,
+	}
+	// The nullable keyword was removed in favor
+	// of a type array that includes "null".
+	switch {
+	case s.Type != "" && s.Nullable:
+		s31.Type = []string{s.Type, "null"}
+	case s.Type != "":
+		s31.Type = s.Type
+	}
+	// A schema without type accepts null, unless
+	// its subschemas don't, in which case null is
+	// added as an alternative.
+	if s.Nullable && s.Type == "" {
+		null := &SchemaOrRef{Schema: &Schema{Type: "null", oas31: true}}
+
+		switch {
+		case len(s.AllOf) == 0 && len(s.OneOf) == 0 && len(s.AnyOf) != 0:
+			s31.AnyOf = append(append([]*SchemaOrRef(nil), s.AnyOf...), null)
+		case len(s.AllOf) == 0 && len(s.AnyOf) == 0 && len(s.OneOf) != 0:
+			s31.OneOf = append(append([]*SchemaOrRef(nil), s.OneOf...), null)
+		case len(s.AllOf) != 0 || len(s.OneOf) != 0 || len(s.AnyOf) != 0:
+			s31.AnyOf = []*SchemaOrRef{{Schema: &Schema{
+				AllOf: s.AllOf,
+				OneOf: s.OneOf,
+				AnyOf: s.AnyOf,
+				oas31: true,
+			}}, null}
+			s31.AllOf, s31.OneOf = nil, nil
+		}
+	}
+	if s.Nullable && len(s.Enum) != 0 {
+		hasNull := false
+		for _, v := range s.Enum {
+			if v == nil {
+				hasNull = true
+				break
+			}
+		}
+		if !hasNull {
+			s31.Enum = append(append([]interface{}(nil), s.Enum...), nil)
+		}
+	}
+	// Exclusive bounds are numbers instead
+	// of boolean modifiers of the inclusive ones.
+	if s.ExclusiveMaximum && s.Maximum != nil {
+		s31.ExclusiveMaximum = s.Maximum
+		s31.Maximum = nil
+	}
+	if s.ExclusiveMinimum && s.Minimum != nil {
+		s31.ExclusiveMinimum = s.Minimum
+		s31.Minimum = nil
+	}
+	if s.Example != nil {
+		s31.Examples = []interface{}{s.Example}
+	}
+	return s31
+}
+
+// convertTo31 returns a copy of the document api
+// whose schemas are marshaled according to the
+// OpenAPI 3.1 specification.
+func convertTo31(api *OpenAPI) *OpenAPI {
+	cpy := *api
+	cpy.OpenAPI = Version31
+	cpy.JSONSchemaDialect = jsonSchemaDialect31
+
+	if api.Paths != nil {
+		cpy.Paths = make(Paths, len(api.Paths))
+		for path, item := range api.Paths {
+			cpy.Paths[path] = pathItemTo31(item)
+		}
+	}
+	if api.Components != nil {
+		c := *api.Components
+		if c.Schemas != nil {
+			c.Schemas = make(map[string]*SchemaOrRef, len(api.Components.Schemas))
+			for name, sor := range api.Components.Schemas {
+				sor = schemaTo31(sor)
+				if sor != nil && sor.Schema != nil {
+					sor.Schema.dialect = jsonSchemaDialect31
+				}
+				c.Schemas[name] = sor
+			}
+		}
+		if c.Responses != nil {
+			c.Responses = make(map[string]*ResponseOrRef, len(api.Components.Responses))
+			for name, ror := range api.Components.Responses {
+				c.Responses[name] = responseTo31(ror)
+			}
+		}
+		if c.Parameters != nil {
+			c.Parameters = make(map[string]*ParameterOrRef, len(api.Components.Parameters))
+			for name, por := range api.Components.Parameters {
+				c.Parameters[name] = parameterTo31(por)
+			}
+		}
+		if c.Headers != nil {
+			c.Headers = make(map[string]*HeaderOrRef, len(api.Components.Headers))
+			for name, hor := range api.Components.Headers {
+				c.Headers[name] = headerTo31(hor)
+			}
+		}
+		cpy.Components = &c
+	}
+	return &cpy
+}
+
+func pathItemTo31(item *PathItem) *PathItem {
+	if item == nil {
+		return nil
+	}
+	cpy := *item
+	cpy.GET = operationTo31(item.GET)
+	cpy.PUT = operationTo31(item.PUT)
+	cpy.POST = operationTo31(item.POST)
+	cpy.DELETE = operationTo31(item.DELETE)
+	cpy.OPTIONS = operationTo31(item.OPTIONS)
+	cpy.HEAD = operationTo31(item.HEAD)
+	cpy.PATCH = operationTo31(item.PATCH)
+	cpy.TRACE = operationTo31(item.TRACE)
+	cpy.Parameters = parametersTo31(item.Parameters)
+
+	return &cpy
+}
+
+func operationTo31(op *Operation) *Operation {
+	if op == nil {
+		return nil
+	}
+	cpy := *op
+	cpy.Parameters = parametersTo31(op.Parameters)
+
+	if op.RequestBody != nil {
+		rb := *op.RequestBody
+		if rb.Content != nil {
+			rb.Content = make(map[string]*MediaType, len(op.RequestBody.Content))
+			for mt, m := range op.RequestBody.Content {
+				rb.Content[mt] = mediaTypeTo31(m)
+			}
+		}
+		cpy.RequestBody = &rb
+	}
+	if op.Responses != nil {
+		cpy.Responses = make(Responses, len(op.Responses))
+		for code, ror := range op.Responses {
+			cpy.Responses[code] = responseTo31(ror)
+		}
+	}
+	return &cpy
+}
+
+func parametersTo31(params []*ParameterOrRef) []*ParameterOrRef {
+	if params == nil {
+		return nil
+	}
+	cpy := make([]*ParameterOrRef, len(params))
+	for i, p := range params {
+		cpy[i] = parameterTo31(p)
+	}
+	return cpy
+}
+
+func parameterTo31(por *ParameterOrRef) *ParameterOrRef {
+	if por == nil || por.Parameter == nil {
+		return por
+	}
+	p := *por.Parameter
+	p.Schema = schemaTo31(p.Schema)
+
+	return &ParameterOrRef{Parameter: &p, Reference: por.Reference}
+}
+
+func responseTo31(ror *ResponseOrRef) *ResponseOrRef {
+	if ror == nil || ror.Response == nil {
+		return ror
+	}
+	r := *ror.Response
+	if r.Headers != nil {
+		r.Headers = make(map[string]*HeaderOrRef, len(ror.Headers))
+		for name, hor := range ror.Headers {
+			r.Headers[name] = headerTo31(hor)
+		}
+	}
+	if r.Content != nil {
+		r.Content = make(map[string]*MediaTypeOrRef, len(ror.Content))
+		for mt, mtor := range ror.Content {
+			if mtor != nil && mtor.MediaType != nil {
+				mtor = &MediaTypeOrRef{MediaType: mediaTypeTo31(mtor.MediaType), Reference: mtor.Reference}
+			}
+			r.Content[mt] = mtor
+		}
+	}
+	return &ResponseOrRef{Response: &r, Reference: ror.Reference}
+}
+
+func headerTo31(hor *HeaderOrRef) *HeaderOrRef {
+	if hor == nil || hor.Header == nil {
+		return hor
+	}
+	h := *hor.Header
+	h.Schema = schemaTo31(h.Schema)
+
+	return &HeaderOrRef{Header: &h, Reference: hor.Reference}
+}
+
+func mediaTypeTo31(m *MediaType) *MediaType {
+	if m == nil {
+		return nil
+	}
+	cpy := *m
+	cpy.Schema = schemaTo31(m.Schema)
+
+	return &cpy
+}
+
+// schemaTo31 returns a deep copy of the schema sor
+// flagged to be marshaled using the OpenAPI 3.1
+// representation.
+func schemaTo31(sor *SchemaOrRef) *SchemaOrRef {
+	if sor == nil || sor.Schema == nil {
+		return sor
+	}
+	s := *sor.Schema
+	s.oas31 = true
+
+	s.AllOf = schemasTo31(s.AllOf)
+	s.OneOf = schemasTo31(s.OneOf)
+	s.AnyOf = schemasTo31(s.AnyOf)
+	s.Items = schemaTo31(s.Items)
+	s.AdditionalProperties = schemaTo31(s.AdditionalProperties)
+
+	if s.Properties != nil {
+		s.Properties = make(map[string]*SchemaOrRef, len(sor.Properties))
+		for name, p := range sor.Properties {
+			s.Properties[name] = schemaTo31(p)
+		}
+	}
+	return &SchemaOrRef{Schema: &s, Reference: sor.Reference}
+}
+
+func schemasTo31(schemas []*SchemaOrRef) []*SchemaOrRef {
+	if schemas == nil {
+		return nil
+	}
+	cpy := make([]*SchemaOrRef, len(schemas))
+	for i, sor := range schemas {
+		cpy[i] = schemaTo31(sor)
+	}
+	return cpy
+}
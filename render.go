@@ -0,0 +1,88 @@
+package fizz
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/loopfz/gadgeto/tonic"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+// Renderer renders the payload of a response
+// with the given status code in a media type.
+type Renderer func(c *gin.Context, status int, payload interface{})
+
+// NegotiatedRenderHook returns a tonic.RenderHook that renders
+// the payload in the media type of the operation response that
+// best matches the Accept header of the request. The renderers
+// extend the built-in JSON, XML and YAML renderers, and may be nil.
+// The responses with no documented media types known by a renderer
+// are rendered by the default render hook of tonic.
+//
+//	tonic.SetRenderHook(fizz.NegotiatedRenderHook(nil), "")
+func NegotiatedRenderHook(renderers map[string]Renderer) tonic.RenderHook {
+	all := map[string]Renderer{
+		binding.MIMEJSON: func(c *gin.Context, status int, payload interface{}) {
+			if gin.IsDebugging() {
+				c.IndentedJSON(status, payload)
+			} else {
+				c.JSON(status, payload)
+			}
+		},
+		binding.MIMEXML: func(c *gin.Context, status int, payload interface{}) {
+			c.XML(status, payload)
+		},
+		binding.MIMEYAML: func(c *gin.Context, status int, payload interface{}) {
+			c.YAML(status, payload)
+		},
+	}
+	for mt, r := range renderers {
+		all[mt] = r
+	}
+	return func(c *gin.Context, statusCode int, payload interface{}) {
+		status := statusCode
+		if c.Writer.Written() {
+			status = c.Writer.Status()
+		}
+		var offers []string
+		if op, err := OperationFromContext(c); err == nil && payload != nil {
+			offers = offeredMediaTypes(op, status, all)
+		}
+		if len(offers) == 0 {
+			tonic.DefaultRenderHook(c, statusCode, payload)
+			return
+		}
+		// Fallback to the first media type if
+		// none is acceptable for the client.
+		mt := c.NegotiateFormat(offers...)
+		if mt == "" {
+			mt = offers[0]
+		}
+		all[mt](c, status, payload)
+	}
+}
+
+// offeredMediaTypes returns the media types of the
+// operation response for the given status that can
+// be rendered, starting with the media type of tonic.
+func offeredMediaTypes(op *openapi.Operation, status int, renderers map[string]Renderer) []string {
+	ror := responseByStatus(op.Responses, status)
+	if ror == nil || ror.Response == nil {
+		return nil
+	}
+	var offers []string
+	for mt := range ror.Content {
+		if _, ok := renderers[mt]; ok {
+			offers = append(offers, mt)
+		}
+	}
+	sort.Slice(offers, func(i, j int) bool {
+		if offers[i] == tonic.MediaType() || offers[j] == tonic.MediaType() {
+			return offers[i] == tonic.MediaType()
+		}
+		return offers[i] < offers[j]
+	})
+	return offers
+}
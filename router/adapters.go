@@ -0,0 +1,119 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ServeMux returns a Router adapter for an http.ServeMux
+// that use the patterns introduced in Go 1.22, with the
+// method of the route as prefix, such as GET /users/{id}.
+// The patterns are enabled only if the go directive of the
+// main module is at least 1.22, or with httpmuxgo121=0 in
+// the GODEBUG setting.
+func ServeMux(mux *http.ServeMux) Router {
+	return &serveMux{mux: mux}
+}
+
+type serveMux struct {
+	mux *http.ServeMux
+}
+
+// Handle implements Router for serveMux.
+func (sm *serveMux) Handle(method, pattern string, h http.Handler) {
+	sm.mux.Handle(method+" "+pattern, h)
+}
+
+// Path implements Router for serveMux.
+func (sm *serveMux) Path(pattern string) string {
+	return ServeMuxPath(pattern)
+}
+
+// ServeMuxPath converts a path pattern of an http.ServeMux
+// to an OpenAPI path template. The wildcards that match the
+// remaining segments, such as {path...}, are converted to a
+// single parameter, and the end anchor {$} is removed.
+func ServeMuxPath(pattern string) string {
+	return rewriteWildcards(pattern, func(w string) string {
+		if w == "$" {
+			return ""
+		}
+		return "{" + strings.TrimSuffix(w, "...") + "}"
+	})
+}
+
+// ChiRouter is the interface implemented by the routers
+// of the github.com/go-chi/chi package, such as chi.Mux.
+type ChiRouter interface {
+	Method(method, pattern string, h http.Handler)
+}
+
+// Chi returns a Router adapter for a chi router.
+func Chi(r ChiRouter) Router {
+	return &chiRouter{r: r}
+}
+
+type chiRouter struct {
+	r ChiRouter
+}
+
+// Handle implements Router for chiRouter.
+func (cr *chiRouter) Handle(method, pattern string, h http.Handler) {
+	cr.r.Method(method, pattern, h)
+}
+
+// Path implements Router for chiRouter.
+func (cr *chiRouter) Path(pattern string) string {
+	return ChiPath(pattern)
+}
+
+// ChiPath converts a path pattern of a chi router to an
+// OpenAPI path template. The regular expressions of the
+// URL parameters, such as {id:[0-9]+}, are removed. The
+// catch-all wildcard * is converted to a parameter named
+// *, which is the key of its value in chi.URLParam.
+func ChiPath(pattern string) string {
+	path := rewriteWildcards(pattern, func(w string) string {
+		if i := strings.IndexByte(w, ':'); i != -1 {
+			w = w[:i]
+		}
+		return "{" + w + "}"
+	})
+	if strings.HasSuffix(path, "*") {
+		path = strings.TrimSuffix(path, "*") + "{*}"
+	}
+	return path
+}
+
+// rewriteWildcards replaces the content of the wildcards
+// of the pattern, delimited by curly braces, using the
+// function fn. Nested braces, that may be used by the
+// regular expressions of a wildcard, are skipped.
+func rewriteWildcards(pattern string, fn func(string) string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		depth, j := 1, i+1
+		for ; j < len(pattern) && depth > 0; j++ {
+			switch pattern[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		if depth != 0 {
+			// Unbalanced braces, keep the
+			// remaining of the pattern as is.
+			b.WriteString(pattern[i:])
+			break
+		}
+		b.WriteString(fn(pattern[i+1 : j-1]))
+		i = j - 1
+	}
+	return b.String()
+}
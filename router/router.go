@@ -0,0 +1,185 @@
+// Package router provides a router-agnostic layer to register
+// the handlers of an API on a net/http compatible router, such
+// as http.ServeMux or chi, and to document them in an OpenAPI
+// specification, the same way Fizz does for Gin.
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/loopfz/gadgeto/tonic"
+	"gopkg.in/yaml.v2"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+// Router is the interface implemented by the
+// adapters of the routers an API can be registered on.
+type Router interface {
+	// Handle registers the handler for the
+	// given method and path pattern.
+	Handle(method, pattern string, h http.Handler)
+
+	// Path converts a path pattern of the router
+	// to an OpenAPI path template.
+	Path(pattern string) string
+}
+
+// Spec represents the documentation of a route.
+type Spec struct {
+	// Input and output models of the handler.
+	// The input model is a struct that use the
+	// location tags of tonic to declare the
+	// parameters, and the output may be nil.
+	Input  interface{}
+	Output interface{}
+
+	// Status code of the default response,
+	// which default to 200.
+	StatusCode int
+
+	// Options of the operation, such as the
+	// operation options of the fizz package.
+	Options []func(*openapi.OperationInfo)
+}
+
+// API registers the handlers of an API on a router
+// and documents them in an OpenAPI specification.
+type API struct {
+	router Router
+	gen    *openapi.Generator
+}
+
+// New returns a new API that registers
+// its handlers on the given router.
+func New(r Router) *API {
+	// Use the same configuration as Fizz, based
+	// on the tags of tonic, so that the models of
+	// the input of the handlers can be shared.
+	gen, _ := openapi.NewGenerator(
+		&openapi.SpecGenConfig{
+			ValidatorTag:      tonic.ValidationTag,
+			PathLocationTag:   tonic.PathTag,
+			QueryLocationTag:  tonic.QueryTag,
+			HeaderLocationTag: tonic.HeaderTag,
+			CookieLocationTag: openapi.CookieTag,
+			FormTag:           openapi.FormTag,
+			EnumTag:           tonic.EnumTag,
+			DefaultTag:        tonic.DefaultTag,
+		},
+	)
+	return &API{
+		router: r,
+		gen:    gen,
+	}
+}
+
+// Generator returns the underlying OpenAPI generator.
+func (a *API) Generator() *openapi.Generator {
+	return a.gen
+}
+
+// Errors returns the errors that may have occurred
+// during the spec generation.
+func (a *API) Errors() []error {
+	return a.gen.Errors()
+}
+
+// GET is a shortcut to register a new handler with the GET method.
+func (a *API) GET(pattern string, h http.Handler, spec *Spec) *API {
+	return a.Handle(http.MethodGet, pattern, h, spec)
+}
+
+// POST is a shortcut to register a new handler with the POST method.
+func (a *API) POST(pattern string, h http.Handler, spec *Spec) *API {
+	return a.Handle(http.MethodPost, pattern, h, spec)
+}
+
+// PUT is a shortcut to register a new handler with the PUT method.
+func (a *API) PUT(pattern string, h http.Handler, spec *Spec) *API {
+	return a.Handle(http.MethodPut, pattern, h, spec)
+}
+
+// PATCH is a shortcut to register a new handler with the PATCH method.
+func (a *API) PATCH(pattern string, h http.Handler, spec *Spec) *API {
+	return a.Handle(http.MethodPatch, pattern, h, spec)
+}
+
+// DELETE is a shortcut to register a new handler with the DELETE method.
+func (a *API) DELETE(pattern string, h http.Handler, spec *Spec) *API {
+	return a.Handle(http.MethodDelete, pattern, h, spec)
+}
+
+// Handle registers the handler for the given method and path
+// pattern on the router, and documents the operation in the
+// specification. The pattern uses the syntax of the router,
+// and a nil spec registers an undocumented route.
+func (a *API) Handle(method, pattern string, h http.Handler, spec *Spec) *API {
+	if spec != nil {
+		oi := &openapi.OperationInfo{}
+		for _, opt := range spec.Options {
+			opt(oi)
+		}
+		path := a.router.Path(pattern)
+
+		// Set an operation ID if none is provided.
+		if oi.ID == "" {
+			oi.ID = openapi.OperationID(method, path)
+		}
+		oi.StatusCode = spec.StatusCode
+		if oi.StatusCode == 0 {
+			oi.StatusCode = http.StatusOK
+		}
+		var in, out reflect.Type
+		if spec.Input != nil {
+			in = reflect.TypeOf(spec.Input)
+		}
+		if spec.Output != nil {
+			out = reflect.TypeOf(spec.Output)
+		}
+		if _, err := a.gen.AddOperation(path, method, "", in, out, oi); err != nil {
+			panic(fmt.Sprintf(
+				"error while generating OpenAPI spec on operation %s %s: %s",
+				method, pattern, err,
+			))
+		}
+	}
+	a.router.Handle(method, pattern, h)
+
+	return a
+}
+
+// OpenAPI returns an http.Handler that serves the
+// marshalled OpenAPI specification of the API.
+func (a *API) OpenAPI(info *openapi.Info, ct string) http.Handler {
+	a.gen.SetInfo(info)
+
+	ct = strings.ToLower(ct)
+	if ct == "" {
+		ct = "json"
+	}
+	var marshal func(interface{}) ([]byte, error)
+	var mediaType string
+
+	switch ct {
+	case "json":
+		marshal, mediaType = json.Marshal, "application/json; charset=utf-8"
+	case "yaml":
+		marshal, mediaType = yaml.Marshal, "application/x-yaml; charset=utf-8"
+	default:
+		panic("invalid content type, use JSON or YAML")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := marshal(a.gen.API())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", mediaType)
+		w.Write(b)
+	})
+}
@@ -0,0 +1,161 @@
+//go:build go1.22
+
+//go:debug httpmuxgo121=0
+
+// The patterns of http.ServeMux require Go 1.22, and
+// the go directive of the module predates them, so the
+// new behavior is enabled with the httpmuxgo121 setting.
+
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wI2L/fizz/openapi"
+)
+
+type (
+	getUserInput struct {
+		ID     string `path:"id"`
+		Fields string `query:"fields"`
+	}
+	getFileInput struct {
+		Path string `path:"path"`
+	}
+	user struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+)
+
+// TestPaths tests the conversion of the path
+// patterns of the routers to OpenAPI templates.
+func TestPaths(t *testing.T) {
+	for pattern, path := range map[string]string{
+		"/users/{id}":             "/users/{id}",
+		"/files/{path...}":        "/files/{path}",
+		"/items/{$}":              "/items/",
+		"/a/{b}/c/{d...}":         "/a/{b}/c/{d}",
+		"/static/{unbalanced":     "/static/{unbalanced",
+		"/users/{id}/posts/{pid}": "/users/{id}/posts/{pid}",
+	} {
+		assert.Equal(t, path, ServeMuxPath(pattern), pattern)
+	}
+	for pattern, path := range map[string]string{
+		"/users/{id}":              "/users/{id}",
+		"/users/{id:[0-9]+}":       "/users/{id}",
+		"/codes/{code:[a-z]{3}}":   "/codes/{code}",
+		"/{year:\\d{4}}/{slug}":    "/{year}/{slug}",
+		"/static/*":                "/static/{*}",
+		"/users/{id:[0-9]+}/posts": "/users/{id}/posts",
+	} {
+		assert.Equal(t, path, ChiPath(pattern), pattern)
+	}
+	assert.Equal(t, "getUsersId", openapi.OperationID("GET", "/users/{id}"))
+}
+
+// TestServeMux tests that the handlers registered
+// with the ServeMux adapter are served and documented.
+func TestServeMux(t *testing.T) {
+	mux := http.NewServeMux()
+	api := New(ServeMux(mux))
+
+	api.GET("/users/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user"))
+	}), &Spec{
+		Input:  &getUserInput{},
+		Output: &user{},
+		Options: []func(*openapi.OperationInfo){
+			func(o *openapi.OperationInfo) { o.Summary = "Get a user" },
+		},
+	})
+	api.GET("/files/{path...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("file"))
+	}), &Spec{
+		Input:      &getFileInput{},
+		StatusCode: http.StatusPartialContent,
+	})
+	mux.Handle("GET /openapi.json", api.OpenAPI(&openapi.Info{Title: "Test", Version: "1.0"}, "json"))
+
+	// Missing path parameters in the input.
+	assert.Panics(t, func() {
+		api.GET("/posts/{id}", http.NotFoundHandler(), &Spec{Input: &getFileInput{}})
+	})
+	// Undocumented route.
+	api.POST("/internal", http.NotFoundHandler(), nil)
+
+	for path, body := range map[string]string{
+		"/users/42":    "user",
+		"/files/a/b/c": "file",
+	} {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code, path)
+		assert.Equal(t, body, recorder.Body.String(), path)
+	}
+	assert.Empty(t, api.Errors())
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	var spec openapi.OpenAPI
+	if err := json.Unmarshal(recorder.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, spec.Paths, "/users/{id}")
+	assert.Contains(t, spec.Paths, "/files/{path}")
+	assert.NotContains(t, spec.Paths, "/internal")
+
+	if op := api.Generator().Operation("/users/{id}", "GET"); assert.NotNil(t, op) {
+		assert.Equal(t, "getUsersId", op.ID)
+		assert.Equal(t, "Get a user", op.Summary)
+		assert.Len(t, op.Parameters, 2)
+		assert.Contains(t, op.Responses, "200")
+	}
+	if op := api.Generator().Operation("/files/{path}", "GET"); assert.NotNil(t, op) {
+		assert.Contains(t, op.Responses, "206")
+	}
+}
+
+type chiMux struct {
+	routes map[string]http.Handler
+}
+
+func (m *chiMux) Method(method, pattern string, h http.Handler) {
+	m.routes[method+" "+pattern] = h
+}
+
+// TestChi tests that the handlers registered with the
+// Chi adapter are registered with their original pattern.
+func TestChi(t *testing.T) {
+	mux := &chiMux{routes: make(map[string]http.Handler)}
+	api := New(Chi(mux))
+
+	api.GET("/users/{id:[0-9]+}", http.NotFoundHandler(), &Spec{
+		Input:  &getUserInput{},
+		Output: &user{},
+	})
+	assert.Contains(t, mux.routes, "GET /users/{id:[0-9]+}")
+	assert.NotNil(t, api.Generator().Operation("/users/{id}", "GET"))
+
+	type staticInput struct {
+		Path string `path:"*"`
+	}
+	api.GET("/static/*", http.NotFoundHandler(), &Spec{
+		Input: &staticInput{},
+	})
+	assert.Contains(t, mux.routes, "GET /static/*")
+	if op := api.Generator().Operation("/static/{*}", "GET"); assert.NotNil(t, op) && assert.Len(t, op.Parameters, 1) {
+		assert.Equal(t, "*", op.Parameters[0].Name)
+	}
+	assert.Empty(t, api.Errors())
+}
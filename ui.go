@@ -0,0 +1,181 @@
+package fizz
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:generate ./ui/fetch.sh
+
+//go:embed ui/*.html ui/dist
+var uiFS embed.FS
+
+// CDN locations of the assets, used when the CDN option
+// is set or when the assets are not embedded. The versions
+// must be kept in sync with those of the ui/fetch.sh script.
+const (
+	swaggerUICDN = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14/"
+	redocCDN     = "https://cdn.jsdelivr.net/npm/redoc@2.1.5/bundles/"
+	rapidocCDN   = "https://cdn.jsdelivr.net/npm/rapidoc@9.3.4/dist/"
+)
+
+// UIOptions represents the options of a
+// documentation page.
+type UIOptions struct {
+	// Title of the HTML page. Default to the
+	// title of the API informations.
+	Title string
+
+	// Config is passed as-is to the viewer.
+	// See the documentation of each viewer for the
+	// list of the supported configuration options.
+	Config map[string]interface{}
+
+	// CDN loads the assets of the viewer from a
+	// public CDN instead of the embedded ones.
+	// The assets missing from ui/dist are always
+	// loaded from the CDN.
+	CDN bool
+}
+
+// uiAsset represents a script or stylesheet of a
+// documentation page, either inlined or loaded
+// from an URL.
+type uiAsset struct {
+	Inline interface{}
+	URL    string
+}
+
+// SwaggerUI returns a Gin HandlerFunc that serves a
+// Swagger UI page for the specification available
+// at the given URL.
+func (f *Fizz) SwaggerUI(specURL string, opts *UIOptions) gin.HandlerFunc {
+	if opts == nil {
+		opts = &UIOptions{}
+	}
+	return f.uiHandler("swagger-ui.html", specURL, opts,
+		[]*uiAsset{jsAsset("swagger-ui-bundle.js", swaggerUICDN, opts.CDN)},
+		[]*uiAsset{cssAsset("swagger-ui.css", swaggerUICDN, opts.CDN)},
+	)
+}
+
+// Redoc returns a Gin HandlerFunc that serves a
+// Redoc page for the specification available
+// at the given URL. The x-logo, x-tagGroups and
+// x-codeSamples vendor extensions of the spec are
+// rendered by Redoc.
+func (f *Fizz) Redoc(specURL string, opts *UIOptions) gin.HandlerFunc {
+	if opts == nil {
+		opts = &UIOptions{}
+	}
+	return f.uiHandler("redoc.html", specURL, opts,
+		[]*uiAsset{jsAsset("redoc.standalone.js", redocCDN, opts.CDN)},
+		nil,
+	)
+}
+
+// RapiDoc returns a Gin HandlerFunc that serves a
+// RapiDoc page for the specification available
+// at the given URL.
+func (f *Fizz) RapiDoc(specURL string, opts *UIOptions) gin.HandlerFunc {
+	if opts == nil {
+		opts = &UIOptions{}
+	}
+	return f.uiHandler("rapidoc.html", specURL, opts,
+		[]*uiAsset{jsAsset("rapidoc-min.js", rapidocCDN, opts.CDN)},
+		nil,
+	)
+}
+
+func (f *Fizz) uiHandler(name, specURL string, opts *UIOptions, scripts, styles []*uiAsset) gin.HandlerFunc {
+	tmpl := template.Must(template.ParseFS(uiFS, "ui/"+name))
+	var (
+		once sync.Once
+		page []byte
+		err  error
+	)
+	return func(c *gin.Context) {
+		// The page is rendered once, on the first
+		// request, because the API informations may
+		// not have been set when the handler is created.
+		once.Do(func() {
+			title := opts.Title
+			if title == "" {
+				if info := f.gen.API().Info; info != nil {
+					title = info.Title
+				}
+			}
+			config := opts.Config
+			if config == nil {
+				config = make(map[string]interface{})
+			}
+			buf := &bytes.Buffer{}
+			err = tmpl.Execute(buf, map[string]interface{}{
+				"Title":   title,
+				"SpecURL": specURL,
+				"Config":  config,
+				"Scripts": scripts,
+				"Styles":  styles,
+			})
+			page = buf.Bytes()
+		})
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
+
+// jsAsset returns the embedded script with the given
+// name, or its CDN location if cdn is true or if the
+// script is not embedded.
+func jsAsset(name, location string, cdn bool) *uiAsset {
+	b, ok := readAsset(name, cdn)
+	if !ok {
+		return &uiAsset{URL: location + name}
+	}
+	// Prevent the script from closing
+	// the inline element prematurely.
+	s := strings.ReplaceAll(string(b), "</script", `<\/script`)
+
+	return &uiAsset{Inline: template.JS(s)}
+}
+
+// cssAsset returns the embedded stylesheet with the
+// given name, or its CDN location if cdn is true or
+// if the stylesheet is not embedded.
+func cssAsset(name, location string, cdn bool) *uiAsset {
+	b, ok := readAsset(name, cdn)
+	if !ok {
+		return &uiAsset{URL: location + name}
+	}
+	s := strings.ReplaceAll(string(b), "</style", `<\/style`)
+
+	return &uiAsset{Inline: template.CSS(s)}
+}
+
+// readAsset returns the content of the embedded asset
+// with the given name, unless cdn is true. A warning is
+// printed in debug mode if the asset is missing.
+func readAsset(name string, cdn bool) ([]byte, bool) {
+	if cdn {
+		return nil, false
+	}
+	b, err := fs.ReadFile(uiFS, "ui/dist/"+name)
+	if err != nil {
+		if gin.IsDebugging() {
+			fmt.Fprintf(gin.DefaultErrorWriter, "[FIZZ] UI asset ui/dist/%s is not embedded, loading it from the CDN\n", name)
+		}
+		return nil, false
+	}
+	return b, true
+}
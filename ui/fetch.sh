@@ -0,0 +1,48 @@
+#!/usr/bin/env bash
+
+# Downloads the assets of the documentation viewers
+# into the ui/dist directory, so that they are embedded
+# in the binary and the pages can be served offline.
+#
+# The assets are verified against the checksums pinned
+# in ui/checksums.sha256. After a version bump, review
+# the new assets and run the script with the -u flag to
+# pin their checksums.
+
+set -euo pipefail
+
+SWAGGER_UI_VERSION="5.17.14"
+REDOC_VERSION="2.1.5"
+RAPIDOC_VERSION="9.3.4"
+
+CDN="https://cdn.jsdelivr.net/npm"
+DIR="$(cd "$(dirname "$0")" && pwd)"
+DIST="$DIR/dist"
+SUMS="$DIR/checksums.sha256"
+
+UPDATE=0
+if [ "${1:-}" = "-u" ]; then
+	UPDATE=1
+fi
+
+TMP="$(mktemp -d)"
+trap 'rm -rf "$TMP"' EXIT
+
+fetch() {
+	curl -sSfL -o "$TMP/$1" "$2"
+}
+
+fetch swagger-ui-bundle.js "$CDN/swagger-ui-dist@$SWAGGER_UI_VERSION/swagger-ui-bundle.js"
+fetch swagger-ui.css "$CDN/swagger-ui-dist@$SWAGGER_UI_VERSION/swagger-ui.css"
+fetch redoc.standalone.js "$CDN/redoc@$REDOC_VERSION/bundles/redoc.standalone.js"
+fetch rapidoc-min.js "$CDN/rapidoc@$RAPIDOC_VERSION/dist/rapidoc-min.js"
+
+if [ "$UPDATE" = 1 ]; then
+	(cd "$TMP" && sha256sum -- *) > "$SUMS"
+elif [ ! -f "$SUMS" ]; then
+	echo "missing $SUMS, review the assets and run $0 -u to pin their checksums" >&2
+	exit 1
+else
+	(cd "$TMP" && sha256sum --strict -c "$SUMS")
+fi
+cp "$TMP"/* "$DIST"
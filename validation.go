@@ -0,0 +1,464 @@
+package fizz
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wI2L/fizz/openapi"
+)
+
+// RequestValidationError is the error returned
+// when a request does not conform to the
+// specification of its operation.
+type RequestValidationError struct {
+	Violations []*openapi.ValueError `json:"violations"`
+}
+
+// Error implements the builtin error interface
+// for RequestValidationError.
+func (rve *RequestValidationError) Error() string {
+	return fmt.Sprintf("request validation failed: %d violation(s)", len(rve.Violations))
+}
+
+// ResponseValidationError is the error reported
+// when a response does not conform to the
+// specification of its operation.
+type ResponseValidationError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Violations []*openapi.ValueError
+}
+
+// Error implements the builtin error interface
+// for ResponseValidationError.
+func (rve *ResponseValidationError) Error() string {
+	var msgs []string
+	for _, v := range rve.Violations {
+		msgs = append(msgs, v.Error())
+	}
+	return fmt.Sprintf("response validation failed for %s %s (%d): %s",
+		rve.Method, rve.Path, rve.StatusCode, strings.Join(msgs, "; "),
+	)
+}
+
+// ResponseViolationFunc is the signature of the
+// functions called with the error that describes
+// the violations of a response.
+type ResponseViolationFunc func(*gin.Context, *ResponseValidationError)
+
+// RequestValidator returns a Gin middleware that
+// validates the path, query, header and cookie parameters
+// and the JSON body of the requests against the
+// specification of their operation. Invalid requests
+// are aborted with a 400 status code and a payload
+// that lists the violations. The pointers of the
+// violations are prefixed with the location of the
+// value, such as /query/limit or /body/name.
+func (f *Fizz) RequestValidator() gin.HandlerFunc {
+	var (
+		once sync.Once
+		api  *openapi.OpenAPI
+	)
+	return func(c *gin.Context) {
+		op := f.operation(c)
+		if op == nil {
+			return
+		}
+		// The document is retrieved on the first request,
+		// once all operations have been registered.
+		once.Do(func() {
+			api = f.gen.API()
+		})
+		violations, err := validateRequest(c, api, op)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		if len(violations) != 0 {
+			err := &RequestValidationError{Violations: violations}
+			c.Error(err)
+
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":      err.Error(),
+				"violations": violations,
+			})
+		}
+	}
+}
+
+// ResponseValidator returns a Gin middleware that
+// buffers the responses and validates their status
+// code and JSON body against the specification of
+// their operation. The violations are reported to
+// the function fn, and the response is sent as-is.
+// If fn is nil, the violations are logged to the
+// default error writer of Gin.
+// Because the responses are buffered, the middleware
+// is intended to be used in tests or in debug mode.
+func (f *Fizz) ResponseValidator(fn ResponseViolationFunc) gin.HandlerFunc {
+	if fn == nil {
+		fn = func(c *gin.Context, err *ResponseValidationError) {
+			fmt.Fprintf(gin.DefaultErrorWriter, "[FIZZ] %s\n", err)
+		}
+	}
+	var (
+		once sync.Once
+		api  *openapi.OpenAPI
+	)
+	return func(c *gin.Context) {
+		op := f.operation(c)
+		if op == nil {
+			return
+		}
+		once.Do(func() {
+			api = f.gen.API()
+		})
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+
+		c.Next()
+
+		c.Writer = w.ResponseWriter
+
+		violations := validateResponse(api, op, w.status, w.Header().Get("Content-Type"), w.buf.Bytes())
+		if len(violations) != 0 {
+			fn(c, &ResponseValidationError{
+				Method:     c.Request.Method,
+				Path:       c.FullPath(),
+				StatusCode: w.status,
+				Violations: violations,
+			})
+		}
+		c.Writer.WriteHeader(w.status)
+		if w.buf.Len() != 0 {
+			c.Writer.Write(w.buf.Bytes())
+		} else {
+			c.Writer.WriteHeaderNow()
+		}
+	}
+}
+
+// DebugResponseValidator returns a Gin middleware that
+// behaves like the one returned by ResponseValidator
+// when Gin runs in debug mode, and that lets the
+// responses through untouched otherwise.
+func (f *Fizz) DebugResponseValidator(fn ResponseViolationFunc) gin.HandlerFunc {
+	validator := f.ResponseValidator(fn)
+
+	return func(c *gin.Context) {
+		if gin.IsDebugging() {
+			validator(c)
+		}
+	}
+}
+
+// errHijackBuffered is the error returned when a
+// handler hijacks the connection of a buffered response.
+var errHijackBuffered = errors.New("hijacking is not supported while the response is buffered")
+
+// bufferedWriter is a gin.ResponseWriter that
+// holds the status code and the body of the
+// response in memory.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	buf     bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.buf.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.buf.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush does nothing, the response is sent
+// once its body has been validated.
+func (w *bufferedWriter) Flush() {}
+
+// Hijack always returns an error, because the
+// response would otherwise be written directly
+// to the connection, bypassing the buffer.
+func (w *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errHijackBuffered
+}
+
+// CloseNotify delegates to the underlying writer,
+// since it writes nothing to the response.
+func (w *bufferedWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.CloseNotify()
+}
+
+// operation returns the specification of the operation
+// that match the request, either from the context or
+// from the generator, in which case the operation can
+// be retrieved in any middleware of the route.
+func (f *Fizz) operation(c *gin.Context) *openapi.Operation {
+	if op, err := OperationFromContext(c); err == nil {
+		return op
+	}
+	if c.FullPath() == "" {
+		return nil
+	}
+	return f.gen.Operation(c.FullPath(), c.Request.Method)
+}
+
+func validateRequest(c *gin.Context, api *openapi.OpenAPI, op *openapi.Operation) ([]*openapi.ValueError, error) {
+	var violations []*openapi.ValueError
+
+	for _, por := range op.Parameters {
+		p := api.ResolveParameter(por)
+		if p == nil {
+			continue
+		}
+		pointer := "/" + p.In + "/" + openapi.JSONPointerToken(p.Name)
+
+		values := paramValues(c, p)
+		if len(values) == 0 {
+			if p.Required {
+				violations = append(violations, &openapi.ValueError{
+					Pointer: pointer,
+					Message: "parameter is required",
+				})
+			}
+			continue
+		}
+		v := parseParamValue(api, p, values)
+		violations = append(violations, openapi.ValidateValue(api, p.Schema, v, pointer)...)
+	}
+	if op.RequestBody != nil {
+		bv, err := validateRequestBody(c, api, op.RequestBody)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, bv...)
+	}
+	return violations, nil
+}
+
+func validateResponse(api *openapi.OpenAPI, op *openapi.Operation, status int, ct string, body []byte) []*openapi.ValueError {
+	resp := api.ResolveResponse(responseByStatus(op.Responses, status))
+	if resp == nil {
+		return []*openapi.ValueError{{
+			Pointer: "/status",
+			Message: fmt.Sprintf("status code %d is not documented", status),
+		}}
+	}
+	if len(bytes.TrimSpace(body)) == 0 || !isJSONMediaType(ct) {
+		return nil
+	}
+	var mt *openapi.MediaTypeOrRef
+	for name, m := range resp.Content {
+		if isJSONMediaType(name) {
+			mt = m
+			break
+		}
+	}
+	if mt == nil || mt.MediaType == nil {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []*openapi.ValueError{{
+			Pointer: "/body",
+			Message: fmt.Sprintf("invalid JSON: %s", err),
+		}}
+	}
+	return openapi.ValidateValue(api, mt.Schema, v, "/body")
+}
+
+// responseByStatus returns the response that match
+// the status code, looking for the exact code first,
+// then for its range, and finally the default one.
+func responseByStatus(responses openapi.Responses, status int) *openapi.ResponseOrRef {
+	code := strconv.Itoa(status)
+	if r, ok := responses[code]; ok {
+		return r
+	}
+	if r, ok := responses[code[:1]+"XX"]; ok {
+		return r
+	}
+	return responses["default"]
+}
+
+func validateRequestBody(c *gin.Context, api *openapi.OpenAPI, rb *openapi.RequestBody) ([]*openapi.ValueError, error) {
+	// Prefer the schema of the media type of the
+	// request when the operation consumes several.
+	mt, ok := rb.Content[c.ContentType()]
+	if !ok || !isJSONMediaType(c.ContentType()) {
+		mt = nil
+		for name, m := range rb.Content {
+			if isJSONMediaType(name) {
+				mt = m
+				break
+			}
+		}
+	}
+	if mt == nil || c.Request.Body == nil {
+		return nil, nil
+	}
+	if ct := c.ContentType(); ct != "" && !isJSONMediaType(ct) {
+		return nil, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	// Restore the body for the next handlers.
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if rb.Required {
+			return []*openapi.ValueError{{
+				Pointer: "/body",
+				Message: "request body is required",
+			}}, nil
+		}
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []*openapi.ValueError{{
+			Pointer: "/body",
+			Message: fmt.Sprintf("invalid JSON: %s", err),
+		}}, nil
+	}
+	return openapi.ValidateValue(api, mt.Schema, v, "/body"), nil
+}
+
+// paramValues returns the raw values of the
+// parameter p from the request.
+func paramValues(c *gin.Context, p *openapi.Parameter) []string {
+	switch p.In {
+	case "path":
+		if v, ok := c.Params.Get(p.Name); ok {
+			return []string{v}
+		}
+	case "query":
+		return c.Request.URL.Query()[p.Name]
+	case "header":
+		return c.Request.Header.Values(p.Name)
+	case "cookie":
+		if v, err := c.Cookie(p.Name); err == nil {
+			return []string{v}
+		}
+	}
+	return nil
+}
+
+// paramStyle returns the serialization style of
+// the parameter p, and whether its array values are
+// exploded. The parameters without a style use the
+// default one of their location, which is exploded
+// for the form style.
+func paramStyle(p *openapi.Parameter) (string, bool) {
+	if p.Style != "" {
+		return p.Style, p.Explode
+	}
+	switch p.In {
+	case "query", "cookie":
+		return "form", true
+	default:
+		return "simple", p.Explode
+	}
+}
+
+// parseParamValue converts the raw values of the
+// parameter p to the type described by its schema.
+// A value that cannot be converted is kept as-is,
+// and reported later by the schema validation.
+func parseParamValue(api *openapi.OpenAPI, p *openapi.Parameter, values []string) interface{} {
+	schema := api.ResolveSchema(p.Schema)
+	if schema == nil {
+		return values[0]
+	}
+	if schema.Type != "array" {
+		return parseValue(schema.Type, values[0])
+	}
+	if style, explode := paramStyle(p); !explode {
+		sep := ","
+		switch style {
+		case "spaceDelimited":
+			sep = " "
+		case "pipeDelimited":
+			sep = "|"
+		}
+		var split []string
+		for _, v := range values {
+			split = append(split, strings.Split(v, sep)...)
+		}
+		values = split
+	}
+	var typ string
+	if items := api.ResolveSchema(schema.Items); items != nil {
+		typ = items.Type
+	}
+	arr := make([]interface{}, len(values))
+	for i, v := range values {
+		arr[i] = parseValue(typ, v)
+	}
+	return arr
+}
+
+func parseValue(typ, s string) interface{} {
+	switch typ {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
+
+func isJSONMediaType(mt string) bool {
+	if i := strings.IndexByte(mt, ';'); i != -1 {
+		mt = mt[:i]
+	}
+	mt = strings.TrimSpace(strings.ToLower(mt))
+
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}